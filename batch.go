@@ -0,0 +1,105 @@
+package envisage
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Pair is an environment variable and its value, as applied by SetPairs
+type Pair struct {
+	Key   string
+	Value string
+}
+
+// SetMany sets every variable in values, sorted by key, as a single change: if any of them can't be set,
+// like a key holding '=' or a value holding a NUL byte, the variables already set are restored to their previous values,
+// or unset if they weren't present, and the error names the failing key.
+func SetMany(values map[string]string) error {
+	return SetPairs(sortedPairs(values)...)
+}
+
+// SetPairs sets the variables in the given order, with the same rollback as SetMany.
+// When a key is repeated, the last value wins.
+func SetPairs(pairs ...Pair) error {
+	return View{}.SetPairs(pairs...)
+}
+
+// SetMany sets every variable in values, with every key prefixed, as the package SetMany does
+func (v View) SetMany(values map[string]string) error {
+	return v.SetPairs(sortedPairs(values)...)
+}
+
+// SetPairs sets the variables in the given order, with every key prefixed, as the package SetPairs does
+func (v View) SetPairs(pairs ...Pair) error {
+	type previous struct {
+		key     string
+		value   string
+		present bool
+	}
+
+	var (
+		undo []previous
+		seen = make(map[string]struct{}, len(pairs))
+	)
+
+	target, err := v.writable()
+	if err != nil {
+		if len(pairs) == 0 {
+			return nil
+		}
+
+		return fmt.Errorf("environment variable %s can't be set: %w", v.Key(pairs[0].Key), err)
+	}
+
+	for _, p := range pairs {
+		key := v.Key(p.Key)
+
+		if _, dup := seen[key]; !dup {
+			seen[key] = struct{}{}
+
+			value, ok, _ := target.Lookup(key)
+			undo = append(undo, previous{key: key, value: value, present: ok})
+		}
+
+		if err := target.Set(key, p.Value); err != nil {
+			err = fmt.Errorf("environment variable %s can't be set: %w", key, err)
+
+			errs := []error{err}
+
+			for i := len(undo) - 1; i >= 0; i-- {
+				u := undo[i]
+
+				var rerr error
+
+				if u.present {
+					rerr = target.Set(u.key, u.value)
+				} else {
+					rerr = target.Unset(u.key)
+				}
+
+				if rerr != nil {
+					errs = append(errs, fmt.Errorf("environment variable %s can't be restored: %w", u.key, rerr))
+				}
+			}
+
+			return errors.Join(errs...)
+		}
+	}
+
+	return nil
+}
+
+func sortedPairs(values map[string]string) []Pair {
+	pairs := make([]Pair, 0, len(values))
+
+	for k, v := range values {
+		pairs = append(pairs, Pair{Key: k, Value: v})
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].Key < pairs[j].Key
+	})
+
+	return pairs
+}
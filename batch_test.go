@@ -0,0 +1,91 @@
+package envisage
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSetMany(t *testing.T) {
+	t.Setenv("T_MANY_A", "old a")
+	t.Setenv("T_MANY_B", "")
+	_ = os.Unsetenv("T_MANY_B")
+
+	if err := SetMany(map[string]string{"T_MANY_A": "new a", "T_MANY_B": "new b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for k, expected := range map[string]string{"T_MANY_A": "new a", "T_MANY_B": "new b"} {
+		if got := Get(k); got != expected {
+			t.Errorf("failed. expecting %s, got %s", expected, got)
+		}
+	}
+}
+
+func TestSetManyRollback(t *testing.T) {
+	type testCase struct {
+		title string
+		pairs []Pair
+		bad   string
+	}
+
+	tests := []testCase{
+		{
+			title: "invalid key",
+			pairs: []Pair{{"T_ROLLBACK_A", "new"}, {"T_ROLLBACK_B", "new"}, {"T_ROLLBACK=C", "x"}},
+			bad:   "T_ROLLBACK=C",
+		},
+		{
+			title: "value with NUL",
+			pairs: []Pair{{"T_ROLLBACK_A", "new"}, {"T_ROLLBACK_A", "newer"}, {"T_ROLLBACK_B", "new"}, {"T_ROLLBACK_C", "x\x00y"}},
+			bad:   "T_ROLLBACK_C",
+		},
+	}
+
+	for _, x := range tests {
+		t.Run(x.title, func(t *testing.T) {
+			t.Setenv("T_ROLLBACK_A", "old")
+			t.Setenv("T_ROLLBACK_B", "")
+			_ = os.Unsetenv("T_ROLLBACK_B")
+
+			err := SetPairs(x.pairs...)
+			if err == nil || !strings.Contains(err.Error(), x.bad) {
+				t.Fatalf("failed. expecting error naming %s, got %v", x.bad, err)
+			}
+
+			if got := Get("T_ROLLBACK_A"); got != "old" {
+				t.Errorf("failed. expecting T_ROLLBACK_A restored to old, got %s", got)
+			}
+
+			if IsThere("T_ROLLBACK_B") {
+				t.Error("failed. expecting T_ROLLBACK_B to be unset again")
+			}
+		})
+	}
+}
+
+func TestLoadFromFileRollback(t *testing.T) {
+	t.Setenv("T_LOAD_ROLLBACK_A", "old")
+
+	path := writeSecretFile(t, "app.env", "T_LOAD_ROLLBACK_A=new\nT_LOAD_ROLLBACK_B=x\x00y\n", 0o600)
+
+	if _, err := LoadFromFile(path, true, false, true); err == nil {
+		t.Fatal("failed. expecting error for a value with NUL")
+	}
+
+	if got := Get("T_LOAD_ROLLBACK_A"); got != "old" {
+		t.Errorf("failed. expecting T_LOAD_ROLLBACK_A to be restored, got %s", got)
+	}
+}
+
+func TestViewSetMany(t *testing.T) {
+	t.Setenv("T_VIEW_MANY_HOST", "")
+
+	if err := WithPrefix("T_VIEW_MANY_").SetMany(map[string]string{"HOST": "db"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := Get("T_VIEW_MANY_HOST"); got != "db" {
+		t.Errorf("failed. expecting db, got %s", got)
+	}
+}
@@ -0,0 +1,481 @@
+package envisage
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	urlType             = reflect.TypeOf(url.URL{})
+	secretType          = reflect.TypeOf(SecretString{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Bind fills the struct pointed by target with environment variables, one per exported field.
+//
+// The key is given by the env tag, like `env:"HOST"`, or derived from the field name, so MaxIdleConns reads MAX_IDLE_CONNS.
+// Tag options follow the name: `env:"HOST,required"` fails if the variable is missing or empty, and `env:"-"` skips the field.
+// The default tag holds the value used when the variable is missing, and sep the list separator for slices, a comma by default.
+// Maps of values are read as by Map, with the sep and kvsep tags, a comma and an equal sign by default.
+//
+// Fields can be strings, booleans, numbers, time.Duration, *url.URL, SecretString, which marks the key as sensitive,
+// types implementing encoding.TextUnmarshaler, like netip.Addr or time.Time, and slices, maps and pointers of those.
+//
+// Nested structs read keys prefixed by their own key and an underscore: DB struct { Primary struct { Host string } }
+// reads DB_PRIMARY_HOST. Embedded structs share the prefix of their parent. Pointers to structs are allocated only
+// if a variable is present under their prefix.
+// Slices of structs read indexed keys, starting at zero, like UPSTREAMS_0_URL and UPSTREAMS_1_URL.
+// Maps of structs read a struct per name found, like TENANTS_ACME_URL for the "ACME" key, so names can't contain underscores.
+// Indexes and names are found by listing the keys of the source set by UseSource, or given to From, the process environment by default.
+//
+// Every problem found is reported, in a *Report, like Validate does.
+func Bind(target any) error {
+	return View{}.Bind(target)
+}
+
+// Bind fills the struct pointed by target as the package Bind does, with every key prefixed
+func (v View) Bind(target any) error {
+	rv := reflect.ValueOf(target)
+
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("bind target must be a non-nil pointer to struct, got %T", target)
+	}
+
+	b := &binder{view: View{source: v.source}, report: &Report{}}
+
+	b.bindStruct(v.prefix, rv.Elem())
+
+	return b.report.Err()
+}
+
+// structField holds what the tags of a struct field declare
+type structField struct {
+	index      int
+	key        string
+	inline     bool
+	required   bool
+	defaultVal string
+	hasDefault bool
+	sep        string
+	kvSep      string
+}
+
+// structFields returns the exported fields of t to be bound, in declaration order
+func structFields(t reflect.Type) []structField {
+	var fields []structField
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+
+		// like encoding/json, embedded structs of unexported types still have their exported fields bound
+		if !sf.IsExported() && !(sf.Anonymous && sf.Type.Kind() == reflect.Struct && isSection(sf.Type)) {
+			continue
+		}
+
+		tag, hasTag := sf.Tag.Lookup("env")
+		if tag == "-" {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+
+		f := structField{
+			index:  i,
+			key:    name,
+			inline: sf.Anonymous && !hasTag,
+			sep:    ",",
+			kvSep:  "=",
+		}
+
+		if f.key == "" {
+			f.key = fieldKey(sf.Name)
+		}
+
+		for _, o := range strings.Split(options, ",") {
+			if o == "required" {
+				f.required = true
+			}
+		}
+
+		f.defaultVal, f.hasDefault = sf.Tag.Lookup("default")
+
+		if s, ok := sf.Tag.Lookup("sep"); ok && s != "" {
+			f.sep = s
+		}
+
+		if s, ok := sf.Tag.Lookup("kvsep"); ok && s != "" {
+			f.kvSep = s
+		}
+
+		fields = append(fields, f)
+	}
+
+	return fields
+}
+
+// fieldKey converts a Go field name to an environment variable name, like MaxIdleConns to MAX_IDLE_CONNS and DBHost to DB_HOST
+func fieldKey(name string) string {
+	runes := []rune(name)
+
+	var sb strings.Builder
+
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				sb.WriteByte('_')
+			}
+		}
+
+		sb.WriteRune(unicode.ToUpper(r))
+	}
+
+	return sb.String()
+}
+
+// isSection returns true for types bound from a group of variables rather than a single one:
+// structs, pointers to structs, and slices and maps of those
+func isSection(t reflect.Type) bool {
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct || t == urlType || t == secretType {
+		return false
+	}
+
+	return !reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+type binder struct {
+	view    View
+	report  *Report
+	allKeys []string
+	listed  bool
+}
+
+func (b *binder) fail(key, value string, kind FailureKind, err error) {
+	b.report.Failures = append(b.report.Failures, Failure{Key: key, Value: value, Kind: kind, Err: err})
+}
+
+// keys returns the keys of the view source, listed once per Bind call
+func (b *binder) keys() []string {
+	if !b.listed {
+		b.allKeys = b.view.activeSource().Keys()
+		b.listed = true
+	}
+
+	return b.allKeys
+}
+
+// segments returns, sorted, the distinct name segments following prefix in the source keys, like ACME for TENANTS_ACME_URL
+func (b *binder) segments(prefix string) []string {
+	seen := make(map[string]struct{})
+
+	var segments []string
+
+	for _, k := range b.keys() {
+		rest, found := strings.CutPrefix(k, prefix)
+		if !found {
+			continue
+		}
+
+		segment, _, found := strings.Cut(rest, "_")
+		if !found || segment == "" {
+			continue
+		}
+
+		if _, dup := seen[segment]; !dup {
+			seen[segment] = struct{}{}
+			segments = append(segments, segment)
+		}
+	}
+
+	sort.Strings(segments)
+
+	return segments
+}
+
+func (b *binder) hasKeys(prefix string) bool {
+	for _, k := range b.keys() {
+		if strings.HasPrefix(k, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (b *binder) bindStruct(prefix string, v reflect.Value) {
+	for _, f := range structFields(v.Type()) {
+		fv := v.Field(f.index)
+		key := prefix + f.key
+
+		switch {
+		case !isSection(fv.Type()):
+			b.bindValue(key, f, fv)
+		case f.inline:
+			b.bindSection(prefix, fv)
+		default:
+			b.bindSection(key+"_", fv)
+		}
+	}
+}
+
+// bindSection binds structs, slices and maps of structs, and pointers to structs, under prefix
+func (b *binder) bindSection(prefix string, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Struct:
+		b.bindStruct(prefix, v)
+	case reflect.Pointer:
+		if v.IsNil() {
+			if !b.hasKeys(prefix) {
+				return
+			}
+
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+
+		b.bindStruct(prefix, v.Elem())
+	case reflect.Slice:
+		b.bindSlice(prefix, v)
+	case reflect.Map:
+		b.bindMap(prefix, v)
+	}
+}
+
+func (b *binder) bindSlice(prefix string, v reflect.Value) {
+	var indexes []int
+
+	for _, segment := range b.segments(prefix) {
+		if i, err := strconv.Atoi(segment); err == nil && i >= 0 && strconv.Itoa(i) == segment {
+			indexes = append(indexes, i)
+		}
+	}
+
+	if len(indexes) == 0 {
+		return
+	}
+
+	sort.Ints(indexes)
+
+	for i, index := range indexes {
+		if i != index {
+			b.fail(prefix+strconv.Itoa(i), "", FailureMissing, fmt.Errorf("indexes must be contiguous from 0, found %d", index))
+
+			return
+		}
+	}
+
+	s := reflect.MakeSlice(v.Type(), len(indexes), len(indexes))
+
+	for i := range indexes {
+		b.bindSection(prefix+strconv.Itoa(i)+"_", s.Index(i))
+	}
+
+	v.Set(s)
+}
+
+func (b *binder) bindMap(prefix string, v reflect.Value) {
+	if v.Type().Key().Kind() != reflect.String {
+		b.fail(strings.TrimSuffix(prefix, "_"), "", FailureInvalid, fmt.Errorf("unsupported map key type %s", v.Type().Key()))
+
+		return
+	}
+
+	names := b.segments(prefix)
+
+	if len(names) == 0 {
+		return
+	}
+
+	if v.IsNil() {
+		v.Set(reflect.MakeMapWithSize(v.Type(), len(names)))
+	}
+
+	for _, name := range names {
+		elem := reflect.New(v.Type().Elem()).Elem()
+
+		b.bindSection(prefix+name+"_", elem)
+
+		v.SetMapIndex(reflect.ValueOf(name).Convert(v.Type().Key()), elem)
+	}
+}
+
+func (b *binder) bindValue(key string, f structField, v reflect.Value) {
+	s, ok, err := b.view.lookup(key)
+	if err != nil {
+		b.fail(key, "", FailureInvalid, err)
+
+		return
+	}
+
+	switch {
+	case ok:
+	case f.hasDefault:
+		s = f.defaultVal
+	case f.required:
+		b.fail(key, "", FailureMissing, nil)
+
+		return
+	default:
+		return
+	}
+
+	if f.required && s == "" {
+		b.fail(key, "", FailureEmpty, nil)
+
+		return
+	}
+
+	if v.Type() == secretType {
+		MarkSensitive(key)
+	}
+
+	if err := setValue(key, f, v, s); err != nil {
+		b.fail(key, s, FailureInvalid, err)
+	}
+}
+
+// setValue converts s to the type of v, and stores it
+func setValue(key string, f structField, v reflect.Value, s string) error {
+	t := v.Type()
+
+	switch {
+	case t == secretType:
+		v.Set(reflect.ValueOf(NewSecret(s)))
+
+		return nil
+	case t == durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return invalidValue(t)
+		}
+
+		v.SetInt(int64(d))
+
+		return nil
+	case t == reflect.PointerTo(urlType):
+		u, err := parseURL(s, URLOptions{})
+		if err != nil {
+			return invalidValue(t)
+		}
+
+		v.Set(reflect.ValueOf(u))
+
+		return nil
+	case reflect.PointerTo(t).Implements(textUnmarshalerType):
+		if err := v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return invalidValue(t)
+		}
+
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		x, err := strconv.ParseBool(s)
+		if err != nil {
+			return invalidValue(t)
+		}
+
+		v.SetBool(x)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x, err := strconv.ParseInt(s, 10, t.Bits())
+		if err != nil {
+			return invalidValue(t)
+		}
+
+		v.SetInt(x)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		x, err := strconv.ParseUint(s, 10, t.Bits())
+		if err != nil {
+			return invalidValue(t)
+		}
+
+		v.SetUint(x)
+	case reflect.Float32, reflect.Float64:
+		x, err := strconv.ParseFloat(s, t.Bits())
+		if err != nil {
+			return invalidValue(t)
+		}
+
+		v.SetFloat(x)
+	case reflect.Pointer:
+		p := reflect.New(t.Elem())
+
+		if err := setValue(key, f, p.Elem(), s); err != nil {
+			return err
+		}
+
+		v.Set(p)
+	case reflect.Slice:
+		items := []string{}
+
+		if s != "" {
+			items = strings.Split(s, f.sep)
+		}
+
+		a := reflect.MakeSlice(t, len(items), len(items))
+
+		for i, item := range items {
+			if t.Elem().Kind() != reflect.String {
+				item = strings.TrimSpace(item)
+			}
+
+			if err := setValue(key, f, a.Index(i), item); err != nil {
+				return fmt.Errorf("item %d: %w", i, err)
+			}
+		}
+
+		v.Set(a)
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return fmt.Errorf("unsupported map key type %s", t.Key())
+		}
+
+		pairs, err := splitPairs(key, s, f.sep, f.kvSep)
+		if err != nil {
+			return err
+		}
+
+		m := reflect.MakeMapWithSize(t, len(pairs))
+
+		for _, pair := range pairs {
+			elem := reflect.New(t.Elem()).Elem()
+
+			if err := setValue(key, f, elem, pair[1]); err != nil {
+				return fmt.Errorf("map key %q: %w", pair[0], err)
+			}
+
+			m.SetMapIndex(reflect.ValueOf(pair[0]).Convert(t.Key()), elem)
+		}
+
+		v.Set(m)
+	default:
+		return fmt.Errorf("unsupported field type %s", t)
+	}
+
+	return nil
+}
+
+// invalidValue reports s didn't convert to t. The parse error is left out on purpose, as it quotes the value.
+func invalidValue(t reflect.Type) error {
+	return fmt.Errorf("not a valid %s", t)
+}
@@ -0,0 +1,167 @@
+package envisage
+
+import (
+	"errors"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testBindDatabase struct {
+	Host     string `env:",required"`
+	Port     int    `default:"5432"`
+	Password SecretString
+}
+
+type testBindUpstream struct {
+	URL    string
+	Weight int `default:"1"`
+}
+
+type testBindTenant struct {
+	Plan  string
+	Seats int
+}
+
+type testBindCommon struct {
+	LogLevel string `default:"info"`
+}
+
+type testBindConfig struct {
+	testBindCommon
+	Name    string `env:"APP_NAME"`
+	Debug   bool
+	Timeout time.Duration `default:"5s"`
+	Ratio   float32
+	Ports   []int `sep:";"`
+	Labels  map[string]string
+	Addr    netip.Addr
+	Ignored string `env:"-"`
+	DB      struct {
+		Primary testBindDatabase
+		Replica *testBindDatabase
+	}
+	Cache     *testBindDatabase
+	Upstreams []testBindUpstream
+	Tenants   map[string]testBindTenant
+}
+
+func TestBind(t *testing.T) {
+	vars := map[string]string{
+		"T_BIND_APP_NAME":            "billing",
+		"T_BIND_DEBUG":               "true",
+		"T_BIND_RATIO":               "0.5",
+		"T_BIND_PORTS":               "80; 443",
+		"T_BIND_LABELS":              "team=core,tier=1",
+		"T_BIND_ADDR":                "10.0.0.1",
+		"T_BIND_IGNORED":             "x",
+		"T_BIND_DB_PRIMARY_HOST":     "primary.local",
+		"T_BIND_DB_PRIMARY_PASSWORD": "hunter2",
+		"T_BIND_DB_REPLICA_HOST":     "replica.local",
+		"T_BIND_DB_REPLICA_PORT":     "6432",
+		"T_BIND_UPSTREAMS_0_URL":     "http://a",
+		"T_BIND_UPSTREAMS_1_URL":     "http://b",
+		"T_BIND_UPSTREAMS_1_WEIGHT":  "3",
+		"T_BIND_TENANTS_ACME_PLAN":   "pro",
+		"T_BIND_TENANTS_ACME_SEATS":  "10",
+		"T_BIND_TENANTS_GLOBEX_PLAN": "free",
+	}
+
+	for k, v := range vars {
+		t.Setenv(k, v)
+	}
+
+	var c testBindConfig
+
+	if err := WithPrefix("T_BIND_").Bind(&c); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := testBindConfig{
+		testBindCommon: testBindCommon{LogLevel: "info"},
+		Name:           "billing",
+		Debug:          true,
+		Timeout:        5 * time.Second,
+		Ratio:          0.5,
+		Ports:          []int{80, 443},
+		Labels:         map[string]string{"team": "core", "tier": "1"},
+		Addr:           netip.MustParseAddr("10.0.0.1"),
+		Upstreams:      []testBindUpstream{{URL: "http://a", Weight: 1}, {URL: "http://b", Weight: 3}},
+		Tenants:        map[string]testBindTenant{"ACME": {Plan: "pro", Seats: 10}, "GLOBEX": {Plan: "free"}},
+	}
+
+	expected.DB.Primary = testBindDatabase{Host: "primary.local", Port: 5432, Password: NewSecret("hunter2")}
+	expected.DB.Replica = &testBindDatabase{Host: "replica.local", Port: 6432}
+
+	if !reflect.DeepEqual(expected, c) {
+		t.Errorf("failed. expecting %+v, got %+v", expected, c)
+	}
+
+	if !IsSensitive("T_BIND_DB_PRIMARY_PASSWORD") {
+		t.Error("failed. expecting SecretString field to mark its key as sensitive")
+	}
+}
+
+func TestBindReportsEveryFailure(t *testing.T) {
+	t.Setenv("T_BINDERR_RATIO", "half")
+	t.Setenv("T_BINDERR_DB_PRIMARY_PORT", "x")
+	t.Setenv("T_BINDERR_UPSTREAMS_0_URL", "http://a")
+	t.Setenv("T_BINDERR_UPSTREAMS_2_URL", "http://c")
+
+	var c testBindConfig
+
+	err := WithPrefix("T_BINDERR_").Bind(&c)
+
+	var report *Report
+
+	if !errors.As(err, &report) {
+		t.Fatalf("failed. expecting *Report, got %v", err)
+	}
+
+	for _, key := range []string{"T_BINDERR_RATIO", "T_BINDERR_DB_PRIMARY_HOST", "T_BINDERR_DB_PRIMARY_PORT", "T_BINDERR_UPSTREAMS_1"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("failed. expecting %s in error %q", key, err)
+		}
+	}
+
+	if !errors.Is(err, ErrMissing) || !errors.Is(err, ErrInvalid) {
+		t.Errorf("failed. expecting missing and invalid failures, got %v", err)
+	}
+
+	if strings.Contains(err.Error(), "half") || !strings.Contains(err.Error(), "T_BINDERR_RATIO is invalid: not a valid float32") {
+		t.Errorf("failed. expecting the parse error without the value, got %q", err)
+	}
+
+	if c.DB.Replica != nil || c.Cache != nil {
+		t.Error("failed. expecting pointers to absent sections to stay nil")
+	}
+}
+
+func TestBindTarget(t *testing.T) {
+	var c testBindConfig
+
+	for _, target := range []any{nil, c, (*testBindConfig)(nil), new(int)} {
+		if err := Bind(target); err == nil {
+			t.Errorf("failed. expecting error binding %T", target)
+		}
+	}
+}
+
+func TestFieldKey(t *testing.T) {
+	tests := map[string]string{
+		"Host":         "HOST",
+		"MaxIdleConns": "MAX_IDLE_CONNS",
+		"DBHost":       "DB_HOST",
+		"PrimaryDB":    "PRIMARY_DB",
+		"HTTP2Port":    "HTTP2_PORT",
+		"URL":          "URL",
+	}
+
+	for name, expected := range tests {
+		if got := fieldKey(name); got != expected {
+			t.Errorf("failed. expecting %s, got %s", expected, got)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+// Command envisage manages encrypted and signed .env files.
+//
+// Usage:
+//
+//	envisage keygen
+//	envisage encrypt [-o output] file
+//	envisage decrypt [-o output] file
+//	envisage rekey [-o output] file
+//	envisage encrypt-value KEY [value]
+//	envisage signkey [-alg ed25519|hmac-sha256]
+//	envisage pubkey -key keyfile
+//	envisage sign -key keyfile [-detached] [-o output] file
+//	envisage verify -key keyfile file
+//
+// Keys are read from ENVISAGE_KEY or the file named by ENVISAGE_KEY_FILE, one base64 key per line or comma separated.
+// The first key encrypts; every key can decrypt, so rotating a key means prepending the new one and running rekey.
+// Output goes to stdout unless -o is given, in which case the file is written with 0600 permissions.
+// encrypt-value prints the value of the variable KEY as ENC[...], ready to be pasted in a .env file as that variable only.
+// Without a value argument, the value is read from stdin, which keeps it out of the shell history.
+//
+// signkey prints a new signing key, and pubkey the public key to verify its signatures, for ed25519 keys.
+// sign appends the signature as a trailing comment, or with -detached writes it to file.sig.
+// verify checks the detached signature if present, the trailing one otherwise, with a signing or public key.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/golangsugar/envisage"
+)
+
+const usage = `usage:
+  envisage keygen
+  envisage encrypt [-o output] file
+  envisage decrypt [-o output] file
+  envisage rekey [-o output] file
+  envisage encrypt-value KEY [value]
+  envisage signkey [-alg ed25519|hmac-sha256]
+  envisage pubkey -key keyfile
+  envisage sign -key keyfile [-detached] [-o output] file
+  envisage verify -key keyfile file
+`
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "envisage:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	command, args := args[0], args[1:]
+
+	if command == "keygen" {
+		key, err := envisage.GenerateKey()
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintln(stdout, key)
+
+		return err
+	}
+
+	if command == "encrypt-value" {
+		return encryptValue(args, stdin, stdout)
+	}
+
+	if command == "signkey" || command == "pubkey" || command == "sign" || command == "verify" {
+		return signature(command, args, stdout)
+	}
+
+	transform, ok := transforms[command]
+	if !ok {
+		return fmt.Errorf("unknown command %q\n%s", command, usage)
+	}
+
+	fs := flag.NewFlagSet(command, flag.ContinueOnError)
+	output := fs.String("o", "", "output file, stdout if empty")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return errors.New(usage)
+	}
+
+	keyring, err := envisage.LoadKeyring()
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	out, err := transform(data, keyring)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fs.Arg(0), err)
+	}
+
+	if *output == "" {
+		_, err = stdout.Write(out)
+
+		return err
+	}
+
+	return os.WriteFile(*output, out, 0o600)
+}
+
+func encryptValue(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) < 1 || len(args) > 2 {
+		return errors.New(usage)
+	}
+
+	keyring, err := envisage.LoadKeyring()
+	if err != nil {
+		return err
+	}
+
+	var value string
+
+	if len(args) == 2 {
+		value = args[1]
+	} else {
+		b, err := io.ReadAll(stdin)
+		if err != nil {
+			return err
+		}
+
+		value = strings.TrimRight(string(b), "\r\n")
+	}
+
+	encrypted, err := envisage.EncryptValue(args[0], value, keyring)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(stdout, encrypted)
+
+	return err
+}
+
+func signature(command string, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet(command, flag.ContinueOnError)
+	algorithm := fs.String("alg", envisage.AlgorithmEd25519, "signature algorithm, ed25519 or hmac-sha256")
+	keyFile := fs.String("key", "", "signing key file, or public key file for verify")
+	detached := fs.Bool("detached", false, "write the signature to file.sig instead of appending it")
+	output := fs.String("o", "", "output file, stdout if empty")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if command == "signkey" {
+		key, err := envisage.GenerateSigningKey(*algorithm)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintln(stdout, key)
+
+		return err
+	}
+
+	wantArgs := 1
+	if command == "pubkey" {
+		wantArgs = 0
+	}
+
+	if *keyFile == "" || fs.NArg() != wantArgs {
+		return errors.New(usage)
+	}
+
+	key, err := os.ReadFile(*keyFile)
+	if err != nil {
+		return err
+	}
+
+	if command == "pubkey" {
+		public, err := envisage.PublicKey(string(key))
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintln(stdout, public)
+
+		return err
+	}
+
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if command == "verify" {
+		verifier, err := envisage.ParseVerifier(string(key))
+		if err != nil {
+			return err
+		}
+
+		sig, err := os.ReadFile(fs.Arg(0) + ".sig")
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		if err := envisage.VerifySignature(data, sig, verifier); err != nil {
+			return fmt.Errorf("%s: %w", fs.Arg(0), err)
+		}
+
+		_, err = fmt.Fprintf(stdout, "%s: signature ok\n", fs.Arg(0))
+
+		return err
+	}
+
+	signer, err := envisage.ParseSigner(string(key))
+	if err != nil {
+		return err
+	}
+
+	if *detached {
+		sig, err := envisage.Sign(data, signer)
+		if err != nil {
+			return err
+		}
+
+		return os.WriteFile(fs.Arg(0)+".sig", []byte(sig), 0o644)
+	}
+
+	out, err := envisage.AppendSignature(data, signer)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		_, err = stdout.Write(out)
+
+		return err
+	}
+
+	return os.WriteFile(*output, out, 0o600)
+}
+
+var transforms = map[string]func([]byte, *envisage.Keyring) ([]byte, error){
+	"encrypt": func(data []byte, keyring *envisage.Keyring) ([]byte, error) {
+		if envisage.IsEncrypted(data) {
+			return nil, errors.New("already encrypted")
+		}
+
+		return envisage.Encrypt(data, keyring)
+	},
+	"decrypt": envisage.Decrypt,
+	"rekey":   envisage.Rekey,
+}
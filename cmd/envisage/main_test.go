@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	var key bytes.Buffer
+
+	if err := run([]string{"keygen"}, nil, &key); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("ENVISAGE_KEY", strings.TrimSpace(key.String()))
+
+	dir := t.TempDir()
+	plainFile := filepath.Join(dir, "prod.env")
+	encryptedFile := filepath.Join(dir, "prod.env.enc")
+
+	if err := os.WriteFile(plainFile, []byte("DB_PASSWORD=hunter2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := run([]string{"encrypt", "-o", encryptedFile, plainFile}, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := run([]string{"encrypt", encryptedFile}, nil, &bytes.Buffer{}); err == nil {
+		t.Error("failed. expecting error encrypting twice")
+	}
+
+	if err := run([]string{"rekey", "-o", encryptedFile, encryptedFile}, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var decrypted bytes.Buffer
+
+	if err := run([]string{"decrypt", encryptedFile}, nil, &decrypted); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := decrypted.String(); got != "DB_PASSWORD=hunter2\n" {
+		t.Errorf("failed. expecting original contents, got %q", got)
+	}
+
+	var value bytes.Buffer
+
+	if err := run([]string{"encrypt-value", "DB_PASSWORD"}, strings.NewReader("hunter2\n"), &value); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := value.String(); !strings.HasPrefix(got, "ENC[v1:") || strings.Contains(got, "hunter2") {
+		t.Errorf("failed. expecting encrypted value, got %q", got)
+	}
+
+	if err := run([]string{"shred", plainFile}, nil, nil); err == nil {
+		t.Error("failed. expecting error for unknown command")
+	}
+}
+
+func TestRunSignature(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "signing.key")
+	publicFile := filepath.Join(dir, "signing.pub")
+	envFile := filepath.Join(dir, "prod.env")
+	signedFile := filepath.Join(dir, "prod.signed.env")
+
+	var key, public bytes.Buffer
+
+	if err := run([]string{"signkey"}, nil, &key); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(keyFile, key.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := run([]string{"pubkey", "-key", keyFile}, nil, &public); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(publicFile, public.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(envFile, []byte("DB_HOST=db\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := run([]string{"verify", "-key", publicFile, envFile}, nil, &bytes.Buffer{}); err == nil {
+		t.Error("failed. expecting unsigned file to fail verification")
+	}
+
+	if err := run([]string{"sign", "-key", keyFile, "-o", signedFile, envFile}, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := run([]string{"verify", "-key", publicFile, signedFile}, nil, &bytes.Buffer{}); err != nil {
+		t.Errorf("failed. expecting trailing signature to verify, got %v", err)
+	}
+
+	if err := run([]string{"sign", "-key", keyFile, "-detached", envFile}, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := run([]string{"verify", "-key", publicFile, envFile}, nil, &bytes.Buffer{}); err != nil {
+		t.Errorf("failed. expecting detached signature to verify, got %v", err)
+	}
+
+	if err := os.WriteFile(envFile, []byte("DB_HOST=attacker\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := run([]string{"verify", "-key", publicFile, envFile}, nil, &bytes.Buffer{}); err == nil {
+		t.Error("failed. expecting modified file to fail verification")
+	}
+
+	if err := run([]string{"sign", "-key", publicFile, envFile}, nil, &bytes.Buffer{}); err == nil {
+		t.Error("failed. expecting public key not to sign")
+	}
+}
@@ -0,0 +1,253 @@
+package envisage
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Variables holding the keys used to decrypt .env files, see LoadKeyring
+const (
+	KeyEnv     = "ENVISAGE_KEY"
+	KeyFileEnv = "ENVISAGE_KEY_FILE"
+)
+
+const (
+	keySize         = 32
+	encryptedHeader = "# envisage:encrypted v1 "
+	encryptedWrap   = 76
+)
+
+// ErrNoKey is returned when encrypted data can't be decrypted with any key in the keyring
+var ErrNoKey = errors.New("no matching key in keyring")
+
+type keyringKey struct {
+	id     string
+	secret []byte
+}
+
+// Keyring holds AES-256 keys used to encrypt and decrypt .env files.
+// The first key is the primary one, used for encryption; the others are kept to decrypt data encrypted before a key rotation.
+type Keyring struct {
+	keys []keyringKey
+}
+
+// GenerateKey returns a new random AES-256 key, base64 encoded, ready to be stored in ENVISAGE_KEY or a key file
+func GenerateKey() (string, error) {
+	b := make([]byte, keySize)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// KeyID returns the identifier recorded in encrypted data, derived from the key itself
+func KeyID(key []byte) string {
+	sum := sha256.Sum256(key)
+
+	return hex.EncodeToString(sum[:8])
+}
+
+// NewKeyring returns a keyring holding the given 32-byte keys, the first one being the primary key
+func NewKeyring(keys ...[]byte) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("keyring needs at least one key")
+	}
+
+	k := &Keyring{}
+
+	for i, key := range keys {
+		if len(key) != keySize {
+			return nil, fmt.Errorf("key %d has %d bytes, expecting %d", i+1, len(key), keySize)
+		}
+
+		k.keys = append(k.keys, keyringKey{id: KeyID(key), secret: append([]byte(nil), key...)})
+	}
+
+	return k, nil
+}
+
+// ParseKeyring returns a keyring from base64 encoded keys separated by commas or line breaks, the first one being the primary key.
+// Blank lines and lines starting with # are ignored.
+func ParseKeyring(s string) (*Keyring, error) {
+	var keys [][]byte
+
+	for _, line := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '\n' || r == '\r' }) {
+		line = strings.TrimSpace(line)
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		b, err := base64.StdEncoding.DecodeString(line)
+		if err != nil {
+			return nil, fmt.Errorf("key %d is not valid base64", len(keys)+1)
+		}
+
+		keys = append(keys, b)
+	}
+
+	return NewKeyring(keys...)
+}
+
+// LoadKeyring returns the keyring in ENVISAGE_KEY or, if not present, in the file named by ENVISAGE_KEY_FILE
+func LoadKeyring() (*Keyring, error) {
+	if s, ok := os.LookupEnv(KeyEnv); ok {
+		k, err := ParseKeyring(s)
+		if err != nil {
+			return nil, fmt.Errorf("environment variable %s: %w", KeyEnv, err)
+		}
+
+		return k, nil
+	}
+
+	path, ok := os.LookupEnv(KeyFileEnv)
+	if !ok {
+		return nil, fmt.Errorf("missing environment variable %s or %s", KeyEnv, KeyFileEnv)
+	}
+
+	s, err := readValueFile(path, FileOptions{MaxSize: defaultFileMaxSize, ForbiddenPerm: 0o077})
+	if err != nil {
+		return nil, fmt.Errorf("environment variable %s: %w", KeyFileEnv, err)
+	}
+
+	k, err := ParseKeyring(s)
+	if err != nil {
+		return nil, fmt.Errorf("environment variable %s: %w", KeyFileEnv, err)
+	}
+
+	return k, nil
+}
+
+// PrimaryID returns the identifier of the key used for encryption
+func (k *Keyring) PrimaryID() string {
+	return k.keys[0].id
+}
+
+// Key returns the key with the given identifier
+func (k *Keyring) Key(id string) ([]byte, error) {
+	for _, key := range k.keys {
+		if key.id == id {
+			return key.secret, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: key id %s", ErrNoKey, id)
+}
+
+// IsEncrypted returns true if data is an encrypted .env file, as produced by Encrypt
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(encryptedHeader))
+}
+
+// Encrypt returns data, usually a .env file, encrypted with the keyring primary key using AES-256-GCM.
+// The output is text, safe to commit: a header line naming the format version and key id, followed by base64 lines.
+// The header is authenticated along with the contents.
+func Encrypt(data []byte, keyring *Keyring) ([]byte, error) {
+	header := encryptedHeader + keyring.PrimaryID()
+
+	sealed, err := seal(keyring.keys[0].secret, data, []byte(header))
+	if err != nil {
+		return nil, err
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(sealed)
+
+	var buf bytes.Buffer
+
+	buf.WriteString(header + "\n")
+
+	for len(encoded) > encryptedWrap {
+		buf.WriteString(encoded[:encryptedWrap] + "\n")
+		encoded = encoded[encryptedWrap:]
+	}
+
+	buf.WriteString(encoded + "\n")
+
+	return buf.Bytes(), nil
+}
+
+// Decrypt returns the contents of data encrypted by Encrypt, using the keyring key named in its header.
+// It fails if the key isn't in the keyring, or if the data was tampered with.
+func Decrypt(data []byte, keyring *Keyring) ([]byte, error) {
+	header, body, _ := bytes.Cut(data, []byte("\n"))
+	header = bytes.TrimRight(header, "\r")
+
+	if !IsEncrypted(header) {
+		return nil, errors.New("data is not encrypted by envisage")
+	}
+
+	key, err := keyring.Key(string(bytes.TrimPrefix(header, []byte(encryptedHeader))))
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(string(body)), ""))
+	if err != nil {
+		return nil, fmt.Errorf("encrypted data is corrupted: %w", err)
+	}
+
+	return unseal(key, sealed, header)
+}
+
+// Rekey decrypts data with any key in the keyring, and encrypts it again with the primary key
+func Rekey(data []byte, keyring *Keyring) ([]byte, error) {
+	plain, err := Decrypt(data, keyring)
+	if err != nil {
+		return nil, err
+	}
+
+	return Encrypt(plain, keyring)
+}
+
+func seal(key, plaintext, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func unseal(key, sealed, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("encrypted data is too short")
+	}
+
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], additionalData)
+	if err != nil {
+		return nil, errors.New("decryption failed: wrong key or tampered data")
+	}
+
+	return plain, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,148 @@
+package envisage
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testKeyring(t *testing.T, n int) (*Keyring, []string) {
+	t.Helper()
+
+	var (
+		keys    [][]byte
+		encoded []string
+	)
+
+	for i := 0; i < n; i++ {
+		k, err := GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b, _ := base64.StdEncoding.DecodeString(k)
+		keys = append(keys, b)
+		encoded = append(encoded, k)
+	}
+
+	keyring, err := NewKeyring(keys...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return keyring, encoded
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	keyring, _ := testKeyring(t, 1)
+	other, _ := testKeyring(t, 1)
+
+	plain := []byte("DB_PASSWORD=hunter2\nAPI_TOKEN=abc123\n")
+
+	encrypted, err := Encrypt(plain, keyring)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !IsEncrypted(encrypted) || bytes.Contains(encrypted, []byte("hunter2")) {
+		t.Fatalf("failed. expecting encrypted data, got %s", encrypted)
+	}
+
+	got, err := Decrypt(encrypted, keyring)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(plain, got) {
+		t.Errorf("failed. expecting %q, got %q", plain, got)
+	}
+
+	if _, err := Decrypt(encrypted, other); !errors.Is(err, ErrNoKey) {
+		t.Errorf("failed. expecting ErrNoKey with the wrong key, got %v", err)
+	}
+
+	tampered := append([]byte(nil), encrypted...)
+	i := bytes.IndexByte(tampered, '\n') + 5
+	tampered[i] ^= 'A' ^ 'B'
+
+	if _, err := Decrypt(tampered, keyring); err == nil {
+		t.Error("failed. expecting error with tampered data")
+	}
+
+	forgedHeader := bytes.Replace(encrypted, []byte("v1"), []byte("v2"), 1)
+
+	if _, err := Decrypt(forgedHeader, keyring); err == nil {
+		t.Error("failed. expecting error with tampered header")
+	}
+}
+
+func TestRekey(t *testing.T) {
+	oldKeyring, oldKeys := testKeyring(t, 1)
+	_, newKeys := testKeyring(t, 1)
+
+	encrypted, err := Encrypt([]byte("A=1\n"), oldKeyring)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated, err := ParseKeyring(newKeys[0] + "\n# previous key\n" + oldKeys[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rekeyed, err := Rekey(encrypted, rotated)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newOnly, err := ParseKeyring(newKeys[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := Decrypt(rekeyed, newOnly); err != nil || string(got) != "A=1\n" {
+		t.Errorf("failed. expecting rekeyed data to decrypt with the new key only, got %q %v", got, err)
+	}
+}
+
+func TestLoadFromEncryptedFile(t *testing.T) {
+	keyring, keys := testKeyring(t, 1)
+
+	encrypted, err := Encrypt([]byte("T_ENCRYPTED_PASSWORD=hunter2\n"), keyring)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "prod.env")
+
+	if err := os.WriteFile(path, encrypted, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(KeyEnv, "")
+	_ = os.Unsetenv(KeyEnv)
+
+	if _, err := LoadFromFile(path, false, false, true); err == nil {
+		t.Error("failed. expecting error without a key")
+	}
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+
+	if err := os.WriteFile(keyFile, []byte(keys[0]+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(KeyFileEnv, keyFile)
+
+	m, err := LoadFromFile(path, false, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := m["T_ENCRYPTED_PASSWORD"]; got != "hunter2" {
+		t.Errorf("failed. expecting hunter2, got %s", got)
+	}
+}
@@ -0,0 +1,231 @@
+package envisage
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// CSVOptions describes how CSV and SetCSV split and join list items.
+// Zero values fall back to RFC 4180 behaviour: items separated by comma, quoted with double quotes,
+// and a quote inside a quoted item escaped by doubling it.
+type CSVOptions struct {
+	// Separator between items. Defaults to ','.
+	Separator rune
+	// Quote encloses items that contain the separator, the quote itself or leading/trailing spaces. Defaults to '"'.
+	Quote rune
+	// Escape precedes a literal quote inside a quoted item. Defaults to Quote, meaning quotes are doubled.
+	Escape rune
+	// TrimSpace removes leading and trailing spaces from unquoted items.
+	TrimSpace bool
+}
+
+func (o CSVOptions) normalized() CSVOptions {
+	if o.Separator == 0 {
+		o.Separator = ','
+	}
+
+	if o.Quote == 0 {
+		o.Quote = '"'
+	}
+
+	if o.Escape == 0 {
+		o.Escape = o.Quote
+	}
+
+	return o
+}
+
+func (o CSVOptions) validate() error {
+	if o.Separator == o.Quote || o.Separator == o.Escape {
+		return fmt.Errorf("csv separator %q can't be used as quote or escape character", o.Separator)
+	}
+
+	for _, r := range []rune{o.Separator, o.Quote, o.Escape} {
+		if r == '\r' || r == '\n' || r == utf8.RuneError {
+			return fmt.Errorf("invalid csv special character %q", r)
+		}
+	}
+
+	return nil
+}
+
+// isRFC4180 reports whether the options can be handled by encoding/csv
+func (o CSVOptions) isRFC4180() bool {
+	return o.Quote == '"' && o.Escape == '"' && !o.TrimSpace
+}
+
+// CSV returns the env var value as []string, splitting it the way a single CSV record is split.
+// Items containing the separator must be quoted, so `"a,b",c` yields ["a,b" "c"].
+// It returns the default value only if the variable is not present. A malformed value returns the default value and an error.
+func CSV(key string, options CSVOptions, defaultValue []string) ([]string, error) {
+	return View{}.CSV(key, options, defaultValue)
+}
+
+// CSV returns the env var value as []string, parsed as a CSV record
+func (v View) CSV(key string, options CSVOptions, defaultValue []string) ([]string, error) {
+	v, key = v.at(key)
+
+	s, ok, err := v.lookup(key)
+	if err != nil || !ok {
+		return defaultValue, err
+	}
+
+	if s == "" {
+		return []string{}, nil
+	}
+
+	items, err := splitCSV(s, options.normalized())
+	if err != nil {
+		return defaultValue, fmt.Errorf("environment variable %s: %w", key, err)
+	}
+
+	return items, nil
+}
+
+// SetCSV sets the value of the environment variable named by the key, joining the items as a single CSV record.
+// Items are quoted only when needed, so the value can be read back with CSV using the same options.
+func SetCSV(key string, options CSVOptions, value []string) error {
+	return View{}.SetCSV(key, options, value)
+}
+
+// SetCSV sets the value of the environment variable named by the key, as a CSV record
+func (v View) SetCSV(key string, options CSVOptions, value []string) error {
+	v, key = v.at(key)
+
+	s, err := joinCSV(value, options.normalized())
+	if err != nil {
+		return fmt.Errorf("environment variable %s: %w", key, err)
+	}
+
+	return v.set(key, s)
+}
+
+func splitCSV(s string, o CSVOptions) ([]string, error) {
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+
+	if o.isRFC4180() {
+		r := csv.NewReader(strings.NewReader(s))
+		r.Comma = o.Separator
+		r.FieldsPerRecord = -1
+
+		record, err := r.Read()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := r.Read(); err == nil {
+			return nil, fmt.Errorf("csv value must be a single line")
+		}
+
+		return record, nil
+	}
+
+	var (
+		items   []string
+		item    strings.Builder
+		rs      = []rune(s)
+		quoted  bool // the current item started with a quote
+		inQuote bool // currently between quotes
+	)
+
+	flush := func() {
+		v := item.String()
+		if o.TrimSpace && !quoted {
+			v = strings.TrimSpace(v)
+		}
+
+		items = append(items, v)
+		item.Reset()
+		quoted = false
+	}
+
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+
+		switch {
+		case inQuote && r == o.Escape && o.Escape != o.Quote && i+1 < len(rs) && (rs[i+1] == o.Quote || rs[i+1] == o.Escape):
+			item.WriteRune(rs[i+1])
+			i++
+		case inQuote && r == o.Quote && o.Escape == o.Quote && i+1 < len(rs) && rs[i+1] == o.Quote:
+			item.WriteRune(o.Quote)
+			i++
+		case inQuote && r == o.Quote:
+			inQuote = false
+
+			for o.TrimSpace && i+1 < len(rs) && rs[i+1] == ' ' {
+				i++
+			}
+
+			if i+1 < len(rs) && rs[i+1] != o.Separator {
+				return nil, fmt.Errorf("unexpected %q after closing quote at position %d", rs[i+1], i+1)
+			}
+		case inQuote:
+			item.WriteRune(r)
+		case r == o.Separator:
+			flush()
+		case r == o.Quote && strings.TrimSpace(item.String()) == "" && !quoted:
+			item.Reset()
+			quoted = true
+			inQuote = true
+		case r == o.Quote:
+			return nil, fmt.Errorf("bare %q in unquoted item at position %d", r, i)
+		default:
+			item.WriteRune(r)
+		}
+	}
+
+	if inQuote {
+		return nil, fmt.Errorf("missing closing %q", o.Quote)
+	}
+
+	flush()
+
+	return items, nil
+}
+
+func joinCSV(items []string, o CSVOptions) (string, error) {
+	if err := o.validate(); err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+
+	for i, item := range items {
+		if i > 0 {
+			sb.WriteRune(o.Separator)
+		}
+
+		if strings.ContainsAny(item, "\r\n") {
+			return "", fmt.Errorf("csv item %q can't contain line breaks", item)
+		}
+
+		needsQuotes := strings.ContainsRune(item, o.Separator) ||
+			strings.ContainsRune(item, o.Quote) ||
+			strings.ContainsRune(item, o.Escape) ||
+			item != strings.TrimSpace(item) ||
+			(item == "" && len(items) == 1)
+
+		if !needsQuotes {
+			sb.WriteString(item)
+			continue
+		}
+
+		sb.WriteRune(o.Quote)
+
+		for _, r := range item {
+			if r == o.Quote || (r == o.Escape && o.Escape != o.Quote) {
+				sb.WriteRune(o.Escape)
+			}
+
+			sb.WriteRune(r)
+		}
+
+		sb.WriteRune(o.Quote)
+	}
+
+	return sb.String(), nil
+}
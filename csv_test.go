@@ -0,0 +1,156 @@
+package envisage
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCSV(t *testing.T) {
+	type testCase struct {
+		title,
+		key,
+		value string
+		options   CSVOptions
+		expected  []string
+		expectErr bool
+	}
+
+	tests := []testCase{
+		{
+			title:    "plain items",
+			key:      "T_CSV_PLAIN",
+			value:    "a,b,c",
+			expected: []string{"a", "b", "c"},
+		},
+		{
+			title:    "quoted item containing the separator",
+			key:      "T_CSV_QUOTED",
+			value:    `https://a.com,"https://b.com?x=1,2"`,
+			expected: []string{"https://a.com", "https://b.com?x=1,2"},
+		},
+		{
+			title:    "doubled quotes",
+			key:      "T_CSV_DOUBLED_QUOTES",
+			value:    `"say ""hi""",x`,
+			expected: []string{`say "hi"`, "x"},
+		},
+		{
+			title:    "empty value",
+			key:      "T_CSV_EMPTY",
+			value:    "",
+			expected: []string{},
+		},
+		{
+			title:    "custom separator, quote and escape",
+			key:      "T_CSV_CUSTOM",
+			value:    `'a;b';'it\'s';c`,
+			options:  CSVOptions{Separator: ';', Quote: '\'', Escape: '\\'},
+			expected: []string{"a;b", "it's", "c"},
+		},
+		{
+			title:    "trim space",
+			key:      "T_CSV_TRIM",
+			value:    ` a , "b " ,c`,
+			options:  CSVOptions{TrimSpace: true, Escape: '\\'},
+			expected: []string{"a", "b ", "c"},
+		},
+		{
+			title:     "missing closing quote",
+			key:       "T_CSV_MISSING_QUOTE",
+			value:     `"a,b`,
+			expectErr: true,
+		},
+		{
+			title:     "bare quote in custom mode",
+			key:       "T_CSV_BARE_QUOTE",
+			value:     `a'b`,
+			options:   CSVOptions{Quote: '\''},
+			expectErr: true,
+		},
+	}
+
+	for _, x := range tests {
+		t.Run(x.title, func(t *testing.T) {
+			t.Setenv(x.key, x.value)
+
+			got, err := CSV(x.key, x.options, nil)
+			if x.expectErr {
+				if err == nil {
+					t.Errorf("failed. expecting error, got %#v", got)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(x.expected, got) {
+				t.Errorf("failed. expecting %#v, got %#v", x.expected, got)
+			}
+		})
+	}
+}
+
+func TestCSVDefaultValue(t *testing.T) {
+	defaultValue := []string{"x"}
+
+	got, err := CSV("T_CSV_NOT_PRESENT_AT_ALL", CSVOptions{}, defaultValue)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(defaultValue, got) {
+		t.Errorf("failed. expecting %#v, got %#v", defaultValue, got)
+	}
+}
+
+func TestSetCSV(t *testing.T) {
+	type testCase struct {
+		title   string
+		options CSVOptions
+		value   []string
+	}
+
+	tests := []testCase{
+		{
+			title: "plain items",
+			value: []string{"a", "b"},
+		},
+		{
+			title: "items needing quotes",
+			value: []string{"a,b", `say "hi"`, " padded ", ""},
+		},
+		{
+			title: "single empty item",
+			value: []string{""},
+		},
+		{
+			title:   "custom quote and escape",
+			options: CSVOptions{Separator: '|', Quote: '\'', Escape: '\\'},
+			value:   []string{"a|b", "it's", `back\slash`},
+		},
+	}
+
+	for _, x := range tests {
+		t.Run(x.title, func(t *testing.T) {
+			const key = "T_SET_CSV"
+
+			t.Setenv(key, "")
+
+			if err := SetCSV(key, x.options, x.value); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := CSV(key, x.options, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(x.value, got) {
+				t.Errorf("failed. expecting %#v, got %#v", x.value, got)
+			}
+		})
+	}
+}
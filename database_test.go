@@ -0,0 +1,161 @@
+package envisage
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadDatabase(t *testing.T) {
+	type testCase struct {
+		title       string
+		env         map[string]string
+		expectedDSN string
+		expectErr   bool
+	}
+
+	keys := DatabaseKeys{
+		URL:      "T_DATABASE_URL",
+		Driver:   "T_DB_DRIVER",
+		Host:     "T_DB_HOST",
+		Port:     "T_DB_PORT",
+		User:     "T_DB_USER",
+		Password: "T_DB_PASSWORD",
+		Name:     "T_DB_NAME",
+	}
+
+	tests := []testCase{
+		{
+			title:       "url only",
+			env:         map[string]string{"T_DATABASE_URL": "postgres://app:secret@db:6543/orders?sslmode=disable"},
+			expectedDSN: "postgres://app:secret@db:6543/orders?sslmode=disable",
+		},
+		{
+			title:       "url with default port",
+			env:         map[string]string{"T_DATABASE_URL": "postgres://app:secret@db/orders"},
+			expectedDSN: "postgres://app:secret@db:5432/orders",
+		},
+		{
+			title: "discrete variables",
+			env: map[string]string{
+				"T_DB_DRIVER":   "postgres",
+				"T_DB_HOST":     "db",
+				"T_DB_USER":     "app",
+				"T_DB_PASSWORD": "p@ss:word",
+				"T_DB_NAME":     "orders",
+			},
+			expectedDSN: "postgres://app:p%40ss%3Aword@db:5432/orders",
+		},
+		{
+			title: "mysql discrete variables",
+			env: map[string]string{
+				"T_DB_DRIVER":   "mysql",
+				"T_DB_HOST":     "db",
+				"T_DB_USER":     "app",
+				"T_DB_PASSWORD": "secret",
+				"T_DB_NAME":     "orders",
+			},
+			expectedDSN: "app:secret@tcp(db:3306)/orders",
+		},
+		{
+			title:       "mysql url",
+			env:         map[string]string{"T_DATABASE_URL": "mysql://app:secret@db:3307/orders?parseTime=true"},
+			expectedDSN: "app:secret@tcp(db:3307)/orders?parseTime=true",
+		},
+		{
+			title: "both shapes agreeing",
+			env: map[string]string{
+				"T_DATABASE_URL": "postgres://app:secret@db/orders",
+				"T_DB_HOST":      "db",
+				"T_DB_PORT":      "5432",
+				"T_DB_NAME":      "orders",
+			},
+			expectedDSN: "postgres://app:secret@db:5432/orders",
+		},
+		{
+			title: "discrete variables without driver",
+			env: map[string]string{
+				"T_DB_HOST": "db",
+				"T_DB_USER": "app",
+				"T_DB_NAME": "orders",
+			},
+			expectErr: true,
+		},
+		{
+			title: "driver alias and case agreeing",
+			env: map[string]string{
+				"T_DATABASE_URL": "postgres://app:secret@db/orders",
+				"T_DB_DRIVER":    "PostgreSQL",
+				"T_DB_HOST":      "db",
+			},
+			expectedDSN: "postgres://app:secret@db:5432/orders",
+		},
+		{
+			title: "both shapes disagreeing",
+			env: map[string]string{
+				"T_DATABASE_URL": "postgres://app:secret@db/orders",
+				"T_DB_HOST":      "replica",
+				"T_DB_PASSWORD":  "other",
+			},
+			expectErr: true,
+		},
+		{
+			title:     "nothing set",
+			expectErr: true,
+		},
+		{
+			title:     "invalid port",
+			env:       map[string]string{"T_DB_HOST": "db", "T_DB_PORT": "five"},
+			expectErr: true,
+		},
+	}
+
+	for _, x := range tests {
+		t.Run(x.title, func(t *testing.T) {
+			for k, v := range x.env {
+				t.Setenv(k, v)
+			}
+
+			got, err := LoadDatabase(keys)
+			if x.expectErr {
+				if err == nil {
+					t.Fatalf("failed. expecting error, got %s", got)
+				}
+
+				if strings.Contains(err.Error(), "secret") || strings.Contains(err.Error(), "other") {
+					t.Errorf("failed. error message leaks the password: %q", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if dsn := got.DSN(); dsn != x.expectedDSN {
+				t.Errorf("failed. expecting %s, got %s", x.expectedDSN, dsn)
+			}
+
+			if strings.Contains(got.String(), "secret") {
+				t.Errorf("failed. String leaks the password: %s", got)
+			}
+		})
+	}
+}
+
+func TestLoadDatabaseDisagreementNamesKeys(t *testing.T) {
+	t.Setenv("T_DIS_DATABASE_URL", "postgres://app@db:5432/orders")
+	t.Setenv("T_DIS_DB_HOST", "replica")
+	t.Setenv("T_DIS_DB_PORT", "6543")
+
+	_, err := LoadDatabase(DatabaseKeys{URL: "T_DIS_DATABASE_URL", Host: "T_DIS_DB_HOST", Port: "T_DIS_DB_PORT", Name: "T_DIS_DB_NAME"})
+	if err == nil {
+		t.Fatal("failed. expecting error")
+	}
+
+	for _, key := range []string{"T_DIS_DATABASE_URL", "T_DIS_DB_HOST", "T_DIS_DB_PORT"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("failed. expecting %s in error message %q", key, err)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package envisage
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DirOptions configures a directory Source, as returned by Dir
+type DirOptions struct {
+	// Normalize maps file names to conventional keys: upper case, with every character other than letters and digits replaced by '_',
+	// so a ConfigMap key log-level is read as LOG_LEVEL. File names are used verbatim otherwise.
+	Normalize bool
+	// Prefix is prepended to every key, after normalization, like "APP_"
+	Prefix string
+	// MaxSize and ForbiddenPerm limit the files accepted, as in FileOptions, with the same defaults
+	MaxSize       int64
+	ForbiddenPerm os.FileMode
+}
+
+// DirSource is a Source reading one value per file from a directory, like Docker secrets in /run/secrets
+// or a Kubernetes ConfigMap volume. Files are read on every lookup, so updated mounts are picked up.
+type DirSource struct {
+	path    string
+	options DirOptions
+}
+
+// Dir returns a Source reading values from the files in path.
+// Hidden entries, Kubernetes' ..data symlink and timestamped directories, and subdirectories are ignored.
+// File contents are trimmed of surrounding spaces and line breaks.
+func Dir(path string, options DirOptions) (*DirSource, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", path)
+	}
+
+	if options.MaxSize <= 0 {
+		options.MaxSize = defaultFileMaxSize
+	}
+
+	if options.ForbiddenPerm == 0 {
+		options.ForbiddenPerm = defaultForbiddenPerm
+	}
+
+	return &DirSource{path: path, options: options}, nil
+}
+
+// Credentials returns a Source reading the systemd credentials in $CREDENTIALS_DIRECTORY, with normalized keys,
+// so a credential loaded with LoadCredential=db-password is read as DB_PASSWORD.
+func Credentials() (*DirSource, error) {
+	path, ok := os.LookupEnv("CREDENTIALS_DIRECTORY")
+	if !ok || path == "" {
+		return nil, errors.New("environment variable CREDENTIALS_DIRECTORY is not set, is the service started by systemd with credentials?")
+	}
+
+	return Dir(path, DirOptions{Normalize: true})
+}
+
+// Lookup implements Source
+func (d *DirSource) Lookup(key string) (string, bool, error) {
+	files, err := d.files()
+	if err != nil {
+		return "", false, err
+	}
+
+	name, ok := files[key]
+	if !ok {
+		return "", false, nil
+	}
+
+	s, err := readValueFile(filepath.Join(d.path, name), FileOptions{MaxSize: d.options.MaxSize, ForbiddenPerm: d.options.ForbiddenPerm})
+	if err != nil {
+		return "", false, fmt.Errorf("environment variable %s: %w", key, err)
+	}
+
+	return s, true, nil
+}
+
+// Keys implements Source
+func (d *DirSource) Keys() []string {
+	files, err := d.files()
+	if err != nil {
+		return nil
+	}
+
+	keys := make([]string, 0, len(files))
+
+	for k := range files {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// files maps keys to file names
+func (d *DirSource) files() (map[string]string, error) {
+	entries, err := os.ReadDir(d.path)
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]string, len(entries))
+
+	for _, e := range entries {
+		name := e.Name()
+
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+
+		if e.IsDir() {
+			continue
+		}
+
+		if e.Type()&fs.ModeSymlink != 0 {
+			// Kubernetes mounts every key as a symlink to ..data/key
+			fi, err := os.Stat(filepath.Join(d.path, name))
+			if err != nil || fi.IsDir() {
+				continue
+			}
+		}
+
+		m[d.key(name)] = name
+	}
+
+	return m, nil
+}
+
+func (d *DirSource) key(name string) string {
+	if d.options.Normalize {
+		name = strings.Map(func(r rune) rune {
+			switch {
+			case r >= 'a' && r <= 'z':
+				return r - 'a' + 'A'
+			case (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'):
+				return r
+			default:
+				return '_'
+			}
+		}, name)
+	}
+
+	return d.options.Prefix + name
+}
@@ -0,0 +1,146 @@
+package envisage
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// createConfigMapDir mimics a Kubernetes ConfigMap volume: keys are symlinks to ..data, itself a symlink to a timestamped directory
+func createConfigMapDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	stamped := filepath.Join(dir, "..2024_01_01_00_00_00.000000000")
+
+	if err := os.Mkdir(stamped, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(stamped, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.Symlink(filepath.Join("..data", name), filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.Symlink(filepath.Base(stamped), filepath.Join(dir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+
+	return dir
+}
+
+func TestDir(t *testing.T) {
+	dir := createConfigMapDir(t, map[string]string{
+		"log-level":   "debug\n",
+		"max.workers": "8",
+	})
+
+	source, err := Dir(dir, DirOptions{Normalize: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected, got := []string{"LOG_LEVEL", "MAX_WORKERS"}, source.Keys(); !reflect.DeepEqual(expected, got) {
+		t.Errorf("failed. expecting keys %v, got %v", expected, got)
+	}
+
+	type testCase struct {
+		key,
+		expected string
+		isThere bool
+	}
+
+	tests := []testCase{
+		{key: "LOG_LEVEL", expected: "debug", isThere: true},
+		{key: "MAX_WORKERS", expected: "8", isThere: true},
+		{key: "..data"},
+		{key: "log-level"},
+	}
+
+	for _, x := range tests {
+		t.Run(x.key, func(t *testing.T) {
+			got, ok, err := source.Lookup(x.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if ok != x.isThere || got != x.expected {
+				t.Errorf("failed. expecting %q %t, got %q %t", x.expected, x.isThere, got, ok)
+			}
+		})
+	}
+}
+
+func TestDirVerbatimWithPrefix(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, "db_password"), []byte("hunter2"), 0o400); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, ".hidden"), []byte("x"), 0o400); err != nil {
+		t.Fatal(err)
+	}
+
+	source, err := Dir(dir, DirOptions{Prefix: "SECRET_"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected, got := []string{"SECRET_db_password"}, source.Keys(); !reflect.DeepEqual(expected, got) {
+		t.Errorf("failed. expecting keys %v, got %v", expected, got)
+	}
+}
+
+func TestDirLayeredWithEnvironment(t *testing.T) {
+	dir := createConfigMapDir(t, map[string]string{"T_DIR_REGION": "eu-west-1", "T_DIR_WORKERS": "8"})
+
+	source, err := Dir(dir, DirOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("T_DIR_WORKERS", "16")
+
+	UseSource(Layered(Environment(), source))
+	defer UseSource(nil)
+
+	if got := String("T_DIR_REGION", ""); got != "eu-west-1" {
+		t.Errorf("failed. expecting value from directory, got %s", got)
+	}
+
+	if got := Int("T_DIR_WORKERS", 0); got != 16 {
+		t.Errorf("failed. expecting environment to override directory, got %d", got)
+	}
+}
+
+func TestCredentials(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, "db-password"), []byte("hunter2\n"), 0o400); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("CREDENTIALS_DIRECTORY", dir)
+
+	source, err := Credentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, ok, err := source.Lookup("DB_PASSWORD"); err != nil || !ok || got != "hunter2" {
+		t.Errorf("failed. expecting hunter2, got %q %t %v", got, ok, err)
+	}
+
+	t.Setenv("CREDENTIALS_DIRECTORY", "")
+
+	if _, err := Credentials(); err == nil {
+		t.Error("failed. expecting error without CREDENTIALS_DIRECTORY")
+	}
+}
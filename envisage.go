@@ -3,28 +3,50 @@ package envisage
 
 import (
 	"fmt"
-	"os"
 	"strconv"
 	"strings"
 )
 
 // IsThere returns true if the variable is present in the environment
 func IsThere(key string) bool {
-	_, ok := os.LookupEnv(key)
+	return View{}.IsThere(key)
+}
+
+// IsThere returns true if the variable is present in the environment
+func (v View) IsThere(key string) bool {
+	v, key = v.at(key)
+
+	_, ok := v.get(key)
 
 	return ok
 }
 
 // Get returns the env var value as string, or empty if the variable is not present
 func Get(key string) string {
-	return os.Getenv(key)
+	return View{}.Get(key)
+}
+
+// Get returns the env var value as string, or empty if the variable is not present
+func (v View) Get(key string) string {
+	v, key = v.at(key)
+
+	s, _ := v.get(key)
+
+	return s
 }
 
 // String returns the env var value as string
 // It returns the default value only if the variable is not present.
 // If the variable is present, but not valued, empty will be returned
 func String(key string, defaultValue string) string {
-	if s, ok := os.LookupEnv(key); ok {
+	return View{}.String(key, defaultValue)
+}
+
+// String returns the env var value as string
+func (v View) String(key string, defaultValue string) string {
+	v, key = v.at(key)
+
+	if s, ok := v.get(key); ok {
 		return s
 	}
 
@@ -34,7 +56,14 @@ func String(key string, defaultValue string) string {
 // Int returns the env var value as int
 // It returns the default value only if either the variable is not present or if its value cannot be correctly converted for int
 func Int(key string, defaultValue int) int {
-	if s, ok := os.LookupEnv(key); ok {
+	return View{}.Int(key, defaultValue)
+}
+
+// Int returns the env var value as int
+func (v View) Int(key string, defaultValue int) int {
+	v, key = v.at(key)
+
+	if s, ok := v.get(key); ok {
 		if i, err := strconv.Atoi(s); err == nil {
 			return i
 		}
@@ -46,7 +75,14 @@ func Int(key string, defaultValue int) int {
 // I64 returns the env var value as int64
 // It returns the default value only if either the variable is not present or if its value cannot be correctly converted for int64
 func I64(key string, defaultValue int64) int64 {
-	if s, ok := os.LookupEnv(key); ok {
+	return View{}.I64(key, defaultValue)
+}
+
+// I64 returns the env var value as int64
+func (v View) I64(key string, defaultValue int64) int64 {
+	v, key = v.at(key)
+
+	if s, ok := v.get(key); ok {
 		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
 			return i
 		}
@@ -59,13 +95,26 @@ func I64(key string, defaultValue int64) int64 {
 // It returns the default value only if either the variable is not present or if its value cannot be correctly converted for int64
 // It's an idiomatic convenience alias for I64
 func Int64(key string, defaultValue int64) int64 {
-	return I64(key, defaultValue)
+	return View{}.Int64(key, defaultValue)
+}
+
+// Int64 returns the env var value as int64
+// It's an idiomatic convenience alias for I64
+func (v View) Int64(key string, defaultValue int64) int64 {
+	return v.I64(key, defaultValue)
 }
 
 // Bool returns the env var value as boolean
 // It returns the default value only if either the variable is not present or if its value cannot be correctly converted for bool
 func Bool(key string, defaultValue bool) bool {
-	if s, ok := os.LookupEnv(key); ok {
+	return View{}.Bool(key, defaultValue)
+}
+
+// Bool returns the env var value as boolean
+func (v View) Bool(key string, defaultValue bool) bool {
+	v, key = v.at(key)
+
+	if s, ok := v.get(key); ok {
 		if b, err := strconv.ParseBool(s); err == nil {
 			return b
 		}
@@ -77,7 +126,14 @@ func Bool(key string, defaultValue bool) bool {
 // F64 returns the env var value as float64
 // It returns the default value only if either the variable is not present or if its value cannot be correctly converted for float64
 func F64(key string, commaDecimalSeparator bool, defaultValue float64) float64 {
-	if s, ok := os.LookupEnv(key); ok {
+	return View{}.F64(key, commaDecimalSeparator, defaultValue)
+}
+
+// F64 returns the env var value as float64
+func (v View) F64(key string, commaDecimalSeparator bool, defaultValue float64) float64 {
+	v, key = v.at(key)
+
+	if s, ok := v.get(key); ok {
 		if commaDecimalSeparator {
 			s = strings.Replace(s, ",", ".", 1)
 		}
@@ -94,12 +150,25 @@ func F64(key string, commaDecimalSeparator bool, defaultValue float64) float64 {
 // It returns the default value only if either the variable is not present or if its value cannot be correctly converted for float64
 // It's an idiomatic convenience alias for F64
 func Float64(key string, commaDecimalSeparator bool, defaultValue float64) float64 {
-	return F64(key, commaDecimalSeparator, defaultValue)
+	return View{}.Float64(key, commaDecimalSeparator, defaultValue)
+}
+
+// Float64 returns the env var value as float64
+// It's an idiomatic convenience alias for F64
+func (v View) Float64(key string, commaDecimalSeparator bool, defaultValue float64) float64 {
+	return v.F64(key, commaDecimalSeparator, defaultValue)
 }
 
 // StringS returns the env var value as []string
 func StringS(key, separator string, defaultValue []string) []string {
-	if s, ok := os.LookupEnv(key); ok {
+	return View{}.StringS(key, separator, defaultValue)
+}
+
+// StringS returns the env var value as []string
+func (v View) StringS(key, separator string, defaultValue []string) []string {
+	v, key = v.at(key)
+
+	if s, ok := v.get(key); ok {
 		if s == "" {
 			return []string{}
 		}
@@ -112,7 +181,19 @@ func StringS(key, separator string, defaultValue []string) []string {
 
 // IntS returns the env var value as []int
 func IntS(key, listItemSeparator string, defaultValue []int) ([]int, error) {
-	if s, ok := os.LookupEnv(key); ok {
+	return View{}.IntS(key, listItemSeparator, defaultValue)
+}
+
+// IntS returns the env var value as []int
+func (v View) IntS(key, listItemSeparator string, defaultValue []int) ([]int, error) {
+	v, key = v.at(key)
+
+	s, ok, err := v.lookup(key)
+	if err != nil {
+		return defaultValue, err
+	}
+
+	if ok {
 		ss := strings.Split(s, listItemSeparator)
 
 		if len(ss) > 0 {
@@ -136,12 +217,30 @@ func IntS(key, listItemSeparator string, defaultValue []int) ([]int, error) {
 // IntSlice returns the env var value as []int
 // It's an idiomatic convenience alias for IntS
 func IntSlice(key, listItemSeparator string, defaultValue []int) ([]int, error) {
-	return IntS(key, listItemSeparator, defaultValue)
+	return View{}.IntSlice(key, listItemSeparator, defaultValue)
+}
+
+// IntSlice returns the env var value as []int
+// It's an idiomatic convenience alias for IntS
+func (v View) IntSlice(key, listItemSeparator string, defaultValue []int) ([]int, error) {
+	return v.IntS(key, listItemSeparator, defaultValue)
 }
 
 // F64S returns the env var value as []float64
 func F64S(key, listItemSeparator string, commaDecimalSeparator bool, defaultValue []float64) ([]float64, error) {
-	if s, ok := os.LookupEnv(key); ok {
+	return View{}.F64S(key, listItemSeparator, commaDecimalSeparator, defaultValue)
+}
+
+// F64S returns the env var value as []float64
+func (v View) F64S(key, listItemSeparator string, commaDecimalSeparator bool, defaultValue []float64) ([]float64, error) {
+	v, key = v.at(key)
+
+	s, ok, err := v.lookup(key)
+	if err != nil {
+		return defaultValue, err
+	}
+
+	if ok {
 		ss := strings.Split(s, listItemSeparator)
 
 		if len(ss) > 0 {
@@ -169,60 +268,243 @@ func F64S(key, listItemSeparator string, commaDecimalSeparator bool, defaultValu
 // Float64Slice returns the env var value as []float64
 // It's an idiomatic convenience alias for F64S
 func Float64Slice(key, listItemSeparator string, commaDecimalSeparator bool, defaultValue []float64) ([]float64, error) {
-	return F64S(key, listItemSeparator, commaDecimalSeparator, defaultValue)
+	return View{}.Float64Slice(key, listItemSeparator, commaDecimalSeparator, defaultValue)
+}
+
+// Float64Slice returns the env var value as []float64
+// It's an idiomatic convenience alias for F64S
+func (v View) Float64Slice(key, listItemSeparator string, commaDecimalSeparator bool, defaultValue []float64) ([]float64, error) {
+	return v.F64S(key, listItemSeparator, commaDecimalSeparator, defaultValue)
 }
 
 // SetString sets the value of the environment variable named by the key.
 func SetString(key, value string) error {
-	return os.Setenv(key, value)
+	return View{}.SetString(key, value)
+}
+
+// SetString sets the value of the environment variable named by the key.
+func (v View) SetString(key, value string) error {
+	v, key = v.at(key)
+
+	return v.set(key, value)
 }
 
 // SetInt sets the value of the environment variable named by the key.
 func SetInt(key string, value int) error {
-	return os.Setenv(key, strconv.Itoa(value))
+	return View{}.SetInt(key, value)
+}
+
+// SetInt sets the value of the environment variable named by the key.
+func (v View) SetInt(key string, value int) error {
+	v, key = v.at(key)
+
+	return v.set(key, strconv.Itoa(value))
 }
 
 // SetI64 sets the value of the environment variable named by the key.
 func SetI64(key string, value int64) error {
-	return os.Setenv(key, strconv.FormatInt(value, 10))
+	return View{}.SetI64(key, value)
+}
+
+// SetI64 sets the value of the environment variable named by the key.
+func (v View) SetI64(key string, value int64) error {
+	v, key = v.at(key)
+
+	return v.set(key, strconv.FormatInt(value, 10))
 }
 
 // SetInt64 sets the value of the environment variable named by the key.
 // It's an idiomatic convenience alias for SetI64
 func SetInt64(key string, value int64) error {
-	return SetI64(key, value)
+	return View{}.SetInt64(key, value)
+}
+
+// SetInt64 sets the value of the environment variable named by the key.
+// It's an idiomatic convenience alias for SetI64
+func (v View) SetInt64(key string, value int64) error {
+	return v.SetI64(key, value)
 }
 
 // SetF64 sets the value of the environment variable named by the key.
 func SetF64(key string, value float64) error {
+	return View{}.SetF64(key, value)
+}
+
+// SetF64 sets the value of the environment variable named by the key.
+func (v View) SetF64(key string, value float64) error {
+	v, key = v.at(key)
+
 	// For implementation details please refer to https://stackoverflow.com/questions/19101419/formatfloat-convert-float-number-to-string/19101700#19101700
-	return os.Setenv(key, strconv.FormatFloat(value, 'f', -1, 64))
+	return v.set(key, strconv.FormatFloat(value, 'f', -1, 64))
 }
 
 // SetFloat64 sets the value of the environment variable named by the key.
 // It's an idiomatic convenience alias for SetF64
 func SetFloat64(key string, value float64) error {
-	return SetF64(key, value)
+	return View{}.SetFloat64(key, value)
+}
+
+// SetFloat64 sets the value of the environment variable named by the key.
+// It's an idiomatic convenience alias for SetF64
+func (v View) SetFloat64(key string, value float64) error {
+	return v.SetF64(key, value)
 }
 
 // SetBool sets the value of the environment variable named by the key.
 func SetBool(key string, value bool) error {
-	return os.Setenv(key, fmt.Sprintf("%t", value))
+	return View{}.SetBool(key, value)
+}
+
+// SetBool sets the value of the environment variable named by the key.
+func (v View) SetBool(key string, value bool) error {
+	v, key = v.at(key)
+
+	return v.set(key, fmt.Sprintf("%t", value))
+}
+
+// SetStringS sets the value of the environment variable named by the key, as items joined by separator, the format read by StringS.
+// Items holding the separator, or a single empty item, can't be read back, and return an error.
+func SetStringS(key, separator string, value []string) error {
+	return View{}.SetStringS(key, separator, value)
+}
+
+// SetStringS sets the value of the environment variable named by the key, in the format read by StringS
+func (v View) SetStringS(key, separator string, value []string) error {
+	v, key = v.at(key)
+
+	s, err := joinItems(key, separator, value)
+	if err != nil {
+		return err
+	}
+
+	return v.set(key, s)
+}
+
+// SetIntS sets the value of the environment variable named by the key, in the format read by IntS.
+// An empty slice is refused, as IntS can't read an empty value.
+func SetIntS(key, listItemSeparator string, value []int) error {
+	return View{}.SetIntS(key, listItemSeparator, value)
+}
+
+// SetIntS sets the value of the environment variable named by the key, in the format read by IntS
+func (v View) SetIntS(key, listItemSeparator string, value []int) error {
+	v, key = v.at(key)
+
+	if len(value) == 0 {
+		return fmt.Errorf("environment variable %s: an empty list can't be read back by IntS", key)
+	}
+
+	items := make([]string, 0, len(value))
+
+	for _, i := range value {
+		items = append(items, strconv.Itoa(i))
+	}
+
+	return v.SetStringS(key, listItemSeparator, items)
+}
+
+// SetIntSlice sets the value of the environment variable named by the key, in the format read by IntS.
+// It's an idiomatic convenience alias for SetIntS
+func SetIntSlice(key, listItemSeparator string, value []int) error {
+	return View{}.SetIntSlice(key, listItemSeparator, value)
+}
+
+// SetIntSlice sets the value of the environment variable named by the key, in the format read by IntS
+// It's an idiomatic convenience alias for SetIntS
+func (v View) SetIntSlice(key, listItemSeparator string, value []int) error {
+	return v.SetIntS(key, listItemSeparator, value)
+}
+
+// SetF64S sets the value of the environment variable named by the key, in the format read by F64S, items formatted as SetF64 does.
+// If commaDecimalSeparator is true, decimals are written after a comma, so the separator can't be a comma.
+// An empty slice is refused, as F64S can't read an empty value.
+func SetF64S(key, listItemSeparator string, commaDecimalSeparator bool, value []float64) error {
+	return View{}.SetF64S(key, listItemSeparator, commaDecimalSeparator, value)
+}
+
+// SetF64S sets the value of the environment variable named by the key, in the format read by F64S
+func (v View) SetF64S(key, listItemSeparator string, commaDecimalSeparator bool, value []float64) error {
+	v, key = v.at(key)
+
+	if len(value) == 0 {
+		return fmt.Errorf("environment variable %s: an empty list can't be read back by F64S", key)
+	}
+
+	if commaDecimalSeparator && strings.Contains(listItemSeparator, ",") {
+		return fmt.Errorf("environment variable %s: separator %q can't hold the decimal comma", key, listItemSeparator)
+	}
+
+	items := make([]string, 0, len(value))
+
+	for _, f := range value {
+		s := strconv.FormatFloat(f, 'f', -1, 64)
+
+		if commaDecimalSeparator {
+			s = strings.Replace(s, ".", ",", 1)
+		}
+
+		items = append(items, s)
+	}
+
+	return v.SetStringS(key, listItemSeparator, items)
+}
+
+// SetFloat64Slice sets the value of the environment variable named by the key, in the format read by F64S.
+// It's an idiomatic convenience alias for SetF64S
+func SetFloat64Slice(key, listItemSeparator string, commaDecimalSeparator bool, value []float64) error {
+	return View{}.SetFloat64Slice(key, listItemSeparator, commaDecimalSeparator, value)
+}
+
+// SetFloat64Slice sets the value of the environment variable named by the key, in the format read by F64S
+// It's an idiomatic convenience alias for SetF64S
+func (v View) SetFloat64Slice(key, listItemSeparator string, commaDecimalSeparator bool, value []float64) error {
+	return v.SetF64S(key, listItemSeparator, commaDecimalSeparator, value)
+}
+
+// joinItems joins list items, failing for items that wouldn't be split back the same way
+func joinItems(key, separator string, items []string) (string, error) {
+	if separator == "" {
+		return "", fmt.Errorf("environment variable %s: list separator can't be empty", key)
+	}
+
+	if len(items) == 1 && items[0] == "" {
+		return "", fmt.Errorf("environment variable %s: a single empty item is read back as an empty list", key)
+	}
+
+	for i, item := range items {
+		if separator != "" && strings.Contains(item, separator) {
+			return "", fmt.Errorf("environment variable %s: item %d can't contain separator %q", key, i, separator)
+		}
+	}
+
+	return strings.Join(items, separator), nil
 }
 
 // Check Test environment variables according given directives.
 // defaultValue returned if the value is not present/set in the environment.
 // twoWay updates the environment with the defaultValue, in case of the environment variable is not present/set.
 // canBeEmpty forces an error if the variable has empty value.
-func Check(key, defaultValue string, twoWay, canBeEmpty bool) error {
-	s, ok := os.LookupEnv(key)
+// constraints are applied, in order, to any non-empty value, like AllowedValues([]string{"debug", "info"}, true).
+func Check(key, defaultValue string, twoWay, canBeEmpty bool, constraints ...Constraint) error {
+	return View{}.Check(key, defaultValue, twoWay, canBeEmpty, constraints...)
+}
+
+// Check Test environment variables according given directives, as the package Check does
+func (v View) Check(key, defaultValue string, twoWay, canBeEmpty bool, constraints ...Constraint) error {
+	v, key = v.at(key)
+
+	s, ok, err := v.lookup(key)
+	if err != nil {
+		return err
+	}
+
 	if !ok {
 		if defaultValue != "" {
 			s = defaultValue
 		}
 
 		if twoWay {
-			if err := SetString(key, s); err != nil {
+			if err := v.SetString(key, s); err != nil {
 				return err
 			}
 		}
@@ -232,5 +514,15 @@ func Check(key, defaultValue string, twoWay, canBeEmpty bool) error {
 		return fmt.Errorf("environment variable %s can't be empty", key)
 	}
 
+	if s == "" {
+		return nil
+	}
+
+	for _, c := range constraints {
+		if err := c(key, s); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
@@ -2,14 +2,18 @@
 package envisage
 
 import (
+	"math"
 	"os"
 	"reflect"
 	"strconv"
 	"strings"
 	"testing"
+	"testing/quick"
 )
 
 func TestSetString(t *testing.T) {
+	RestoreOnCleanup(t)
+
 	type testCase struct {
 		title,
 		key,
@@ -48,6 +52,8 @@ func TestSetString(t *testing.T) {
 }
 
 func TestSetInt(t *testing.T) {
+	RestoreOnCleanup(t)
+
 	type testCase struct {
 		title,
 		key string
@@ -88,6 +94,8 @@ func TestSetInt(t *testing.T) {
 }
 
 func TestSetI64(t *testing.T) {
+	RestoreOnCleanup(t)
+
 	type testCase struct {
 		title,
 		key string
@@ -128,6 +136,8 @@ func TestSetI64(t *testing.T) {
 }
 
 func TestSetF64(t *testing.T) {
+	RestoreOnCleanup(t)
+
 	type testCase struct {
 		title,
 		key string
@@ -173,6 +183,8 @@ func TestSetF64(t *testing.T) {
 }
 
 func TestSetBool(t *testing.T) {
+	RestoreOnCleanup(t)
+
 	type testCase struct {
 		title,
 		key string
@@ -208,6 +220,8 @@ func TestSetBool(t *testing.T) {
 }
 
 func TestIsThere(t *testing.T) {
+	RestoreOnCleanup(t)
+
 	type testCase struct {
 		title,
 		key,
@@ -251,6 +265,8 @@ func TestIsThere(t *testing.T) {
 }
 
 func TestString(t *testing.T) {
+	RestoreOnCleanup(t)
+
 	type testCase struct {
 		title,
 		key,
@@ -303,6 +319,8 @@ func TestString(t *testing.T) {
 }
 
 func TestInt(t *testing.T) {
+	RestoreOnCleanup(t)
+
 	type testCase struct {
 		title,
 		key string
@@ -350,6 +368,8 @@ func TestInt(t *testing.T) {
 }
 
 func TestI64(t *testing.T) {
+	RestoreOnCleanup(t)
+
 	type testCase struct {
 		title,
 		key string
@@ -397,6 +417,8 @@ func TestI64(t *testing.T) {
 }
 
 func TestF64(t *testing.T) {
+	RestoreOnCleanup(t)
+
 	type testCase struct {
 		title,
 		key string
@@ -444,6 +466,8 @@ func TestF64(t *testing.T) {
 }
 
 func TestBool(t *testing.T) {
+	RestoreOnCleanup(t)
+
 	type testCase struct {
 		title,
 		key string
@@ -493,6 +517,8 @@ func TestBool(t *testing.T) {
 }
 
 func TestStringS(t *testing.T) {
+	RestoreOnCleanup(t)
+
 	type testCase struct {
 		title,
 		key string
@@ -695,3 +721,55 @@ func F64S(key, listItemSeparator string, commaDecimalSeparator bool, defaultValu
 }
 
 */
+
+func TestSetSlicesRoundTrip(t *testing.T) {
+	t.Setenv("T_ROUNDTRIP_SLICE", "")
+
+	stringS := func(value []string) bool {
+		err := SetStringS("T_ROUNDTRIP_SLICE", ";", value)
+		if err != nil {
+			// only values that can't be written, or read back the same way, are refused
+			return (len(value) == 1 && value[0] == "") || strings.Contains(strings.Join(value, ""), ";") || strings.ContainsRune(strings.Join(value, ""), 0)
+		}
+
+		got := StringS("T_ROUNDTRIP_SLICE", ";", nil)
+
+		return len(got) == len(value) && (len(value) == 0 || reflect.DeepEqual(value, got))
+	}
+
+	intS := func(value []int) bool {
+		if err := SetIntS("T_ROUNDTRIP_SLICE", ",", value); err != nil {
+			return len(value) == 0 // an empty value reads back as an error
+		}
+
+		got, err := IntS("T_ROUNDTRIP_SLICE", ",", nil)
+
+		return err == nil && reflect.DeepEqual(value, got)
+	}
+
+	f64S := func(value []float64, commaDecimalSeparator bool) bool {
+		if err := SetF64S("T_ROUNDTRIP_SLICE", ";", commaDecimalSeparator, value); err != nil {
+			return len(value) == 0 // an empty value reads back as an error
+		}
+
+		got, err := F64S("T_ROUNDTRIP_SLICE", ";", commaDecimalSeparator, nil)
+
+		return err == nil && len(got) == len(value) && (len(value) == 0 || reflect.DeepEqual(value, got))
+	}
+
+	for name, property := range map[string]any{"StringS": stringS, "IntS": intS, "F64S": f64S} {
+		if err := quick.Check(property, nil); err != nil {
+			t.Errorf("failed. %s doesn't round trip: %v", name, err)
+		}
+	}
+
+	for _, f := range []float64{math.MaxFloat64, math.SmallestNonzeroFloat64, -0.1, 1e21} {
+		if !f64S([]float64{f}, false) {
+			t.Errorf("failed. expecting %v to round trip", f)
+		}
+	}
+
+	if err := SetF64S("T_ROUNDTRIP_SLICE", ",", true, []float64{1.5}); err == nil {
+		t.Error("failed. expecting error for comma separator with decimal comma")
+	}
+}
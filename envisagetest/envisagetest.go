@@ -0,0 +1,194 @@
+// Package envisagetest helps testing code that uses envisage without touching the process environment.
+//
+// Env is an in-memory Source: give it to the code under test as a View made by envisage.From, and tests can run in parallel.
+// Code reading through the package functions can still be tested with Use, but not in parallel.
+package envisagetest
+
+import (
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/golangsugar/envisage"
+)
+
+// Env is a map-backed envisage.WritableSource, safe for concurrent use
+type Env struct {
+	mu   sync.RWMutex
+	vars map[string]string
+}
+
+// New returns an Env holding a copy of vars
+func New(vars map[string]string) *Env {
+	e := &Env{vars: make(map[string]string, len(vars))}
+
+	for k, v := range vars {
+		e.vars[k] = v
+	}
+
+	return e
+}
+
+// FromDotEnv returns an Env holding the variables of text, in the .env format read by envisage.LoadFromFile
+func FromDotEnv(text string) *Env {
+	return New(envisage.ParseDotEnv([]byte(text)))
+}
+
+// With sets the variable and returns e, so fixtures can be chained
+func (e *Env) With(key, value string) *Env {
+	_ = e.Set(key, value)
+
+	return e
+}
+
+// WithDotEnv sets the variables of text, in the .env format, and returns e
+func (e *Env) WithDotEnv(text string) *Env {
+	for k, v := range envisage.ParseDotEnv([]byte(text)) {
+		_ = e.Set(k, v)
+	}
+
+	return e
+}
+
+// View returns a View reading from and writing to e
+func (e *Env) View() envisage.View {
+	return envisage.From(e)
+}
+
+// Lookup returns the value of the variable named by the key, and whether it is present
+func (e *Env) Lookup(key string) (string, bool, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	v, ok := e.vars[key]
+
+	return v, ok, nil
+}
+
+// Keys returns, sorted, the name of every variable present
+func (e *Env) Keys() []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	keys := make([]string, 0, len(e.vars))
+
+	for k := range e.vars {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Set sets the value of the variable named by the key
+func (e *Env) Set(key, value string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.vars[key] = value
+
+	return nil
+}
+
+// Unset removes the variable named by the key
+func (e *Env) Unset(key string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	delete(e.vars, key)
+
+	return nil
+}
+
+// Map returns a copy of the variables
+func (e *Env) Map() map[string]string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	m := make(map[string]string, len(e.vars))
+
+	for k, v := range e.vars {
+		m[k] = v
+	}
+
+	return m
+}
+
+// Use makes every envisage getter read from source, and setters write to it, until the test ends.
+// The source is global, so don't use it in parallel tests: give them a View made by envisage.From instead.
+func Use(t testing.TB, source envisage.Source) {
+	t.Helper()
+
+	envisage.UseSource(source)
+
+	t.Cleanup(func() {
+		envisage.UseSource(nil)
+	})
+}
+
+// Reader is what the assertions read from, like envisage.View or an envisage.Source
+type Reader interface {
+	Lookup(key string) (string, bool, error)
+}
+
+// RequireKey returns the value of the variable named by the key, and stops the test if it's not present or can't be read
+func RequireKey(t testing.TB, r Reader, key string) string {
+	t.Helper()
+
+	v, ok, err := r.Lookup(key)
+	if err != nil {
+		t.Fatalf("envisagetest: %v", err)
+	}
+
+	if !ok {
+		t.Fatalf("envisagetest: environment variable %s is not present", fullKey(r, key))
+	}
+
+	return v
+}
+
+// AssertLoaded reports every variable of expected that isn't present in r with the expected value, sorted by key.
+// Values of sensitive keys, as marked by envisage.MarkSensitive, are masked in the messages.
+func AssertLoaded(t testing.TB, r Reader, expected map[string]string) {
+	t.Helper()
+
+	keys := make([]string, 0, len(expected))
+
+	for k := range expected {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v, ok, err := r.Lookup(k)
+		key := fullKey(r, k)
+
+		switch {
+		case err != nil:
+			t.Errorf("envisagetest: %v", err)
+		case !ok:
+			t.Errorf("envisagetest: environment variable %s is not present", key)
+		case v != expected[k]:
+			t.Errorf("envisagetest: environment variable %s: expecting %s, got %s", key, mask(key, expected[k]), mask(key, v))
+		}
+	}
+}
+
+// fullKey returns the key with the prefix of r, if it's a View
+func fullKey(r Reader, key string) string {
+	if v, ok := r.(envisage.View); ok {
+		return v.Key(key)
+	}
+
+	return key
+}
+
+func mask(key, value string) string {
+	if envisage.IsSensitive(key) {
+		return envisage.Redacted
+	}
+
+	return value
+}
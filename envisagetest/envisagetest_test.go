@@ -0,0 +1,205 @@
+package envisagetest
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/golangsugar/envisage"
+)
+
+// recorder collects the failures reported by the assertions, instead of failing the test
+type recorder struct {
+	testing.TB
+	failures []string
+	fatal    bool
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.Errorf(format, args...)
+	r.fatal = true
+	runtime.Goexit()
+}
+
+// record runs fn in its own goroutine, as Fatalf stops it
+func record(t *testing.T, fn func(r *recorder)) *recorder {
+	r := &recorder{TB: t}
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+		fn(r)
+	}()
+
+	wg.Wait()
+
+	return r
+}
+
+func TestEnvView(t *testing.T) {
+	type testCase struct {
+		title string
+		text  string
+		port  int
+		hosts []string
+	}
+
+	tests := []testCase{
+		{title: "single host", text: "APP_PORT=8080\nAPP_HOSTS=a.local", port: 8080, hosts: []string{"a.local"}},
+		{title: "comments and hosts", text: "# fixture\nAPP_PORT=9090\n\nAPP_HOSTS=a.local,b.local\n", port: 9090, hosts: []string{"a.local", "b.local"}},
+		{title: "missing port", text: "APP_HOSTS=", port: 80, hosts: []string{}},
+	}
+
+	for _, x := range tests {
+		x := x
+
+		t.Run(x.title, func(t *testing.T) {
+			t.Parallel()
+
+			env := FromDotEnv(x.text)
+			app := env.View().WithPrefix("APP_")
+
+			if got := app.Int("PORT", 80); got != x.port {
+				t.Errorf("failed. expecting %d, got %d", x.port, got)
+			}
+
+			if got := app.StringS("HOSTS", ",", nil); !reflect.DeepEqual(x.hosts, got) {
+				t.Errorf("failed. expecting %v, got %v", x.hosts, got)
+			}
+
+			if err := app.SetString("MODE", x.title); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := RequireKey(t, env, "APP_MODE"); got != x.title {
+				t.Errorf("failed. expecting setter to write to the fake, got %s", got)
+			}
+
+			if _, ok := os.LookupEnv("APP_MODE"); ok {
+				t.Error("failed. expecting the process environment untouched")
+			}
+		})
+	}
+}
+
+func TestEnvBind(t *testing.T) {
+	t.Parallel()
+
+	var c struct {
+		Host    string `env:",required"`
+		Port    int    `default:"5432"`
+		Replica struct {
+			Host string
+		}
+	}
+
+	env := New(map[string]string{"DB_HOST": "primary.local", "DB_REPLICA_HOST": "replica.local"}).With("DB_PORT", "6432")
+
+	if err := env.View().WithPrefix("DB_").Bind(&c); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Host != "primary.local" || c.Port != 6432 || c.Replica.Host != "replica.local" {
+		t.Errorf("failed. expecting values from the fake, got %+v", c)
+	}
+
+	if err := env.View().SetPairs(envisage.Pair{Key: "A", Value: "1"}, envisage.Pair{Key: "B", Value: "2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	AssertLoaded(t, env, map[string]string{"A": "1", "B": "2", "DB_PORT": "6432"})
+}
+
+func TestUse(t *testing.T) {
+	env := New(map[string]string{"T_ENVISAGETEST_USE": "fake"})
+
+	t.Run("use", func(t *testing.T) {
+		Use(t, env)
+
+		if got := envisage.String("T_ENVISAGETEST_USE", ""); got != "fake" {
+			t.Errorf("failed. expecting fake, got %s", got)
+		}
+
+		if err := envisage.SetInt("T_ENVISAGETEST_SET", 1); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if got := envisage.String("T_ENVISAGETEST_USE", "restored"); got != "restored" {
+		t.Errorf("failed. expecting source restored, got %s", got)
+	}
+
+	if _, ok := os.LookupEnv("T_ENVISAGETEST_SET"); ok {
+		t.Error("failed. expecting setter to write to the fake")
+	}
+
+	if expected, got := map[string]string{"T_ENVISAGETEST_SET": "1", "T_ENVISAGETEST_USE": "fake"}, env.Map(); !reflect.DeepEqual(expected, got) {
+		t.Errorf("failed. expecting %v, got %v", expected, got)
+	}
+}
+
+func TestRequireKey(t *testing.T) {
+	t.Parallel()
+
+	env := New(map[string]string{"PRESENT": "x"})
+
+	r := record(t, func(r *recorder) {
+		RequireKey(r, env, "PRESENT")
+	})
+
+	if r.fatal {
+		t.Errorf("failed. expecting no failure, got %v", r.failures)
+	}
+
+	r = record(t, func(r *recorder) {
+		RequireKey(r, env, "MISSING")
+		r.Errorf("not stopped")
+	})
+
+	if !r.fatal || len(r.failures) != 1 || !strings.Contains(r.failures[0], "MISSING") {
+		t.Errorf("failed. expecting test stopped naming MISSING, got %v", r.failures)
+	}
+}
+
+func TestAssertLoaded(t *testing.T) {
+	t.Parallel()
+
+	envisage.MarkSensitive("T_ENVISAGETEST_TOKEN")
+
+	env := New(map[string]string{"T_ENVISAGETEST_HOST": "a.local", "T_ENVISAGETEST_TOKEN": "hunter2"})
+
+	r := record(t, func(r *recorder) {
+		AssertLoaded(r, env.View().WithPrefix("T_ENVISAGETEST_"), map[string]string{
+			"HOST":  "b.local",
+			"PORT":  "80",
+			"TOKEN": "hunter3",
+		})
+	})
+
+	if len(r.failures) != 3 {
+		t.Fatalf("failed. expecting 3 failures, got %v", r.failures)
+	}
+
+	for i, expected := range []string{"T_ENVISAGETEST_HOST: expecting b.local, got a.local", "T_ENVISAGETEST_PORT is not present", "expecting [REDACTED], got [REDACTED]"} {
+		if !strings.Contains(r.failures[i], expected) {
+			t.Errorf("failed. expecting %s, got %s", expected, r.failures[i])
+		}
+	}
+
+	if strings.Contains(strings.Join(r.failures, "\n"), "hunter") {
+		t.Error("failed. expecting sensitive values masked")
+	}
+}
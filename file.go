@@ -2,8 +2,12 @@ package envisage
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
 	"os"
 	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -15,21 +19,27 @@ const (
 
 var rxConfig = regexp.MustCompile(configRegex)
 
-func envMap(configFile string, errorIfFileDoesntExist bool) (map[string]string, error) {
-	f, err := os.Open(configFile)
-	if err != nil {
-		if err == os.ErrNotExist && !errorIfFileDoesntExist {
-			return nil, nil
+func envMap(configFile string, data []byte) (map[string]string, error) {
+	data, _ = splitSignature(data)
+
+	if IsEncrypted(data) {
+		keyring, err := LoadKeyring()
+		if err != nil {
+			return nil, fmt.Errorf("%s is encrypted: %w", configFile, err)
 		}
 
-		return nil, err
+		if data, err = Decrypt(data, keyring); err != nil {
+			return nil, fmt.Errorf("%s: %w", configFile, err)
+		}
 	}
 
-	defer func() {
-		_ = f.Close()
-	}()
+	return ParseDotEnv(data), nil
+}
 
-	scanner := bufio.NewScanner(f)
+// ParseDotEnv returns the variables of a .env file, in the format read by LoadFromFile, without touching the environment.
+// Empty lines, comments and lines that aren't KEY=VALUE are skipped. Encrypted files aren't decrypted, use Load for them.
+func ParseDotEnv(data []byte) map[string]string {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
 
 	scanner.Split(bufio.ScanLines)
 
@@ -54,14 +64,179 @@ func envMap(configFile string, errorIfFileDoesntExist bool) (map[string]string,
 		}
 	}
 
-	return m, nil
+	return m
+}
+
+// PermissionCheck sets what Load does with files holding sensitive values that other users can access
+type PermissionCheck int
+
+// Permission checks, from the most lenient
+const (
+	// PermissionsIgnored skips the check, the default
+	PermissionsIgnored PermissionCheck = iota
+	// PermissionsWarn reports problems in LoadResult.Warnings
+	PermissionsWarn
+	// PermissionsError makes Load fail with ErrUnsafePermissions
+	PermissionsError
+)
+
+// ErrUnsafePermissions is returned by Load when a file holding sensitive values is readable by group or others, or owned by another user
+var ErrUnsafePermissions = errors.New("unsafe file permissions")
+
+// LoadOptions configures Load
+type LoadOptions struct {
+	// UpdateEnvironment sets every valid variable found on environment as well, with SetMany, so either all are set or none
+	UpdateEnvironment bool
+	// SkipIfAlreadyDefined keeps variables already defined on environment, they are still returned in Values
+	SkipIfAlreadyDefined bool
+	// ErrorIfFileDoesntExist makes Load fail if the file doesn't exist, instead of returning no values
+	ErrorIfFileDoesntExist bool
+	// Verifier, if not nil, makes Load refuse files without a valid signature, see Sign and AppendSignature.
+	// The detached signature file is checked if present, the trailing signature comment otherwise.
+	Verifier Verifier
+	// SignatureFile is the detached signature file. Defaults to the file name followed by .sig, like .env.sig
+	SignatureFile string
+	// Permissions checks, on Linux, the mode and owner of files holding plain text values of keys marked with MarkSensitive.
+	// Mark keys before calling Load. ENC[...] values, secret:// references and encrypted files are not considered sensitive here.
+	Permissions PermissionCheck
+}
+
+// LoadResult describes a file read by Load
+type LoadResult struct {
+	// Values holds the variables found in the file, resolved like the getters do
+	Values map[string]string
+	// Verified is true if the file signature was checked
+	Verified bool
+	// Warnings lists the problems found by PermissionsWarn
+	Warnings []string
+}
+
+// Load reads environment variables values from a given text file, like LoadFromFile, with extra checks set in options
+func Load(configFile string, options LoadOptions) (*LoadResult, error) {
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && !options.ErrorIfFileDoesntExist {
+			return &LoadResult{}, nil
+		}
+
+		return nil, err
+	}
+
+	result := &LoadResult{}
+
+	if options.Verifier != nil {
+		if err := verifyFile(configFile, data, options); err != nil {
+			return nil, err
+		}
+
+		result.Verified = true
+	}
+
+	m, err := envMap(configFile, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Permissions != PermissionsIgnored {
+		warning, err := checkPermissions(configFile, data, m)
+		if err != nil {
+			return nil, err
+		}
+
+		if warning != "" && options.Permissions == PermissionsError {
+			return nil, fmt.Errorf("%w: %s", ErrUnsafePermissions, warning)
+		}
+
+		if warning != "" {
+			result.Warnings = append(result.Warnings, warning)
+		}
+	}
+
+	result.Values = make(map[string]string, len(m))
+
+	for k, v := range m {
+		if result.Values[k], err = resolveValue(k, v); err != nil {
+			return nil, fmt.Errorf("%s: %w", configFile, err)
+		}
+	}
+
+	if !options.UpdateEnvironment {
+		return result, nil
+	}
+
+	target, _ := View{}.writable() // the default View always has one
+	updates := make(map[string]string, len(m))
+
+	for k, v := range m {
+		if _, ok, _ := target.Lookup(k); ok && options.SkipIfAlreadyDefined {
+			continue
+		}
+
+		updates[k] = v
+	}
+
+	if err := SetMany(updates); err != nil {
+		return nil, fmt.Errorf("%s: %w", configFile, err)
+	}
+
+	return result, nil
+}
+
+// checkPermissions describes why a file holding plain text sensitive values is unsafe, or returns an empty string
+func checkPermissions(configFile string, data []byte, m map[string]string) (string, error) {
+	if body, _ := splitSignature(data); IsEncrypted(body) {
+		return "", nil
+	}
+
+	var keys []string
+
+	for k, v := range m {
+		if IsSensitive(k) && v != "" && !IsEncryptedValue(v) && !IsSecretRef(v) {
+			keys = append(keys, k)
+		}
+	}
+
+	if len(keys) == 0 {
+		return "", nil
+	}
+
+	problems, err := permissionProblems(configFile)
+	if err != nil || len(problems) == 0 {
+		return "", err
+	}
+
+	sort.Strings(keys)
+
+	return fmt.Sprintf("%s holds sensitive keys %s but is %s", configFile, strings.Join(keys, ", "), strings.Join(problems, " and ")), nil
+}
+
+func verifyFile(configFile string, data []byte, options LoadOptions) error {
+	signatureFile := options.SignatureFile
+	if signatureFile == "" {
+		signatureFile = configFile + ".sig"
+	}
+
+	detached, err := os.ReadFile(signatureFile)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if err = VerifySignature(data, detached, options.Verifier); err != nil {
+		return fmt.Errorf("%s: %w", configFile, err)
+	}
+
+	return nil
 }
 
 // LoadFromFile loads environment variables values from a given text file in to a map[string]string.
 // configFile is the file name, with the complete path if necessary.
-// if updateEnvironment is true, all valid variable values found will be set on environment as well.
+// if updateEnvironment is true, all valid variable values found will be set on environment as well, or none if any of them fails.
 // if skipIfAlreadyDefined is true, the found variable will be added to the map anyway, but only updated in environment if not defined.
 // if errorIfFileDoesntExist, the function returns with an error in case of the given file doesn't exist.
+// Files encrypted with Encrypt, or the envisage encrypt command, are decrypted with the keyring returned by LoadKeyring.
+// ENC[...] values and secret:// references are resolved in the returned map, but set on environment as they are,
+// so only the getters see them in plain text.
+// A trailing signature comment, as added by AppendSignature, is ignored; use Load to verify it.
 // valid lines must comply with regex ^([A-Z][A-Z0-9_]+)([=]{1})([[\S ]*]?)$.
 // Examples of valid lines:
 // ABC=prd
@@ -76,28 +251,14 @@ func envMap(configFile string, errorIfFileDoesntExist bool) (map[string]string,
 // Invalid/Ignored: X=4334343434 ( should contain 2 or more chars ).
 // Environment variables reference for curious: https://pubs.opengroup.org/onlinepubs/9699919799/basedefs/V1_chap08.html.
 func LoadFromFile(configFile string, updateEnvironment, skipIfAlreadyDefined, errorIfFileDoesntExist bool) (map[string]string, error) {
-	m, err := envMap(configFile, errorIfFileDoesntExist)
+	result, err := Load(configFile, LoadOptions{
+		UpdateEnvironment:      updateEnvironment,
+		SkipIfAlreadyDefined:   skipIfAlreadyDefined,
+		ErrorIfFileDoesntExist: errorIfFileDoesntExist,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if m == nil {
-		return nil, nil
-	}
-
-	if !updateEnvironment {
-		return m, nil
-	}
-
-	for k, v := range m {
-		if _, ok := os.LookupEnv(k); ok && skipIfAlreadyDefined {
-			continue
-		}
-
-		if serr := os.Setenv(k, v); serr != nil {
-			return nil, serr
-		}
-	}
-
-	return m, nil
+	return result.Values, nil
 }
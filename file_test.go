@@ -2,6 +2,8 @@ package envisage
 
 import (
 	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -77,6 +79,19 @@ func createFile(tests []fileTestCase) error {
 	return nil
 }
 
+func TestLoadFromFileMissing(t *testing.T) {
+	path := t.TempDir() + "/missing.env"
+
+	m, err := LoadFromFile(path, true, false, false)
+	if err != nil || len(m) != 0 {
+		t.Errorf("failed. expecting no error and no values, got %v, %v", m, err)
+	}
+
+	if _, err := LoadFromFile(path, true, false, true); !os.IsNotExist(err) {
+		t.Errorf("failed. expecting a not exist error, got %v", err)
+	}
+}
+
 func TestLoadFromFile(t *testing.T) {
 	tests := createLoadFromFileTestCases()
 
@@ -130,3 +145,36 @@ func TestLoadFromFile(t *testing.T) {
 		}
 	}
 }
+
+func TestParseDotEnv(t *testing.T) {
+	m := ParseDotEnv([]byte("# comment\n\nHOST=db.local\n  PORT=5432  \nnot a pair\nEMPTY=\n"))
+
+	if expected := map[string]string{"HOST": "db.local", "PORT": "5432", "EMPTY": ""}; !reflect.DeepEqual(expected, m) {
+		t.Errorf("failed. expecting %v, got %v", expected, m)
+	}
+}
+
+func TestLoadSkipsKeysDefinedInWritableSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+
+	if err := os.WriteFile(path, []byte("T_LOADSRC_HOST=file.local\nT_LOADSRC_PORT=5432\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("T_LOADSRC_HOST", "os.local")
+
+	source := testWritableSource{"T_LOADSRC_PORT": "6432"}
+
+	UseSource(source)
+	defer UseSource(nil)
+
+	if _, err := Load(path, LoadOptions{UpdateEnvironment: true, SkipIfAlreadyDefined: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := testWritableSource{"T_LOADSRC_HOST": "file.local", "T_LOADSRC_PORT": "6432"}
+
+	if !reflect.DeepEqual(expected, source) {
+		t.Errorf("failed. expecting %v, got %v", expected, source)
+	}
+}
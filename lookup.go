@@ -0,0 +1,234 @@
+package envisage
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// FileOptions configures how KEY_FILE variables are resolved, as enabled by EnableFileIndirection
+type FileOptions struct {
+	// Suffix appended to the key to name the variable holding the file path. Defaults to "_FILE".
+	Suffix string
+	// MaxSize is the largest file accepted, in bytes. Defaults to 64 KiB.
+	MaxSize int64
+	// ForbiddenPerm lists permission bits that make a file rejected. Defaults to 0o002, world-writable.
+	// Use 0o077 to accept only files private to their owner, but mind Kubernetes mounts secrets as 0o644 by default.
+	ForbiddenPerm os.FileMode
+}
+
+const (
+	defaultFileSuffix    = "_FILE"
+	defaultFileMaxSize   = 64 << 10
+	defaultForbiddenPerm = 0o002
+)
+
+var (
+	lookupMu       sync.RWMutex
+	currentSource  Source
+	fileOptions    *FileOptions
+	onLookupFailed = discardLookupError
+)
+
+// EnableFileIndirection makes every getter read the file named by KEY_FILE when KEY is not present,
+// following the Docker and Kubernetes secrets convention, like DB_PASSWORD_FILE=/run/secrets/db_password.
+// The file contents are trimmed of surrounding spaces and line breaks. If both variables are present, KEY wins.
+func EnableFileIndirection(options FileOptions) {
+	if options.Suffix == "" {
+		options.Suffix = defaultFileSuffix
+	}
+
+	if options.MaxSize <= 0 {
+		options.MaxSize = defaultFileMaxSize
+	}
+
+	if options.ForbiddenPerm == 0 {
+		options.ForbiddenPerm = defaultForbiddenPerm
+	}
+
+	lookupMu.Lock()
+	defer lookupMu.Unlock()
+
+	fileOptions = &options
+}
+
+// DisableFileIndirection turns off the KEY_FILE resolution enabled by EnableFileIndirection
+func DisableFileIndirection() {
+	lookupMu.Lock()
+	defer lookupMu.Unlock()
+
+	fileOptions = nil
+}
+
+// SetErrorHandler sets the function called when a getter that doesn't return errors, like String or Int,
+// can't resolve a value, like when KEY_FILE names an unreadable file. The getter then falls back to its default value.
+// By default, and after SetErrorHandler(nil), errors are discarded.
+// It returns the previous handler, so it can be restored, like in tests.
+func SetErrorHandler(handler func(key string, err error)) func(key string, err error) {
+	if handler == nil {
+		handler = discardLookupError
+	}
+
+	lookupMu.Lock()
+	defer lookupMu.Unlock()
+
+	previous := onLookupFailed
+	onLookupFailed = handler
+
+	return previous
+}
+
+func discardLookupError(string, error) {}
+
+// Lookup returns the value of the variable named by the key, and whether it is present.
+// Unlike os.LookupEnv, it reads from the source set by UseSource and applies the resolution every getter does,
+// like KEY_FILE indirection, ENC[...] decryption and secret:// references, and reports its errors.
+func Lookup(key string) (string, bool, error) {
+	return View{}.Lookup(key)
+}
+
+// Lookup returns the value of the variable named by the key, and whether it is present, as the package Lookup does
+func (v View) Lookup(key string) (string, bool, error) {
+	return v.lookup(v.Key(key))
+}
+
+func lookup(key string) (string, bool, error) {
+	return View{}.lookup(key)
+}
+
+// lookup reads the full key from the view source
+func (v View) lookup(key string) (string, bool, error) {
+	s, ok, err := lookupRaw(v.activeSource(), key)
+	if err != nil || !ok {
+		return "", false, err
+	}
+
+	if s, err = resolveValue(key, s); err != nil {
+		return "", false, err
+	}
+
+	return s, true, nil
+}
+
+// lookupRaw reads the value from source, or from KEY_FILE, without resolving it
+func lookupRaw(source Source, key string) (string, bool, error) {
+	if s, ok, err := source.Lookup(key); err != nil || ok {
+		return s, ok, err
+	}
+
+	lookupMu.RLock()
+	options := fileOptions
+	lookupMu.RUnlock()
+
+	if options == nil {
+		return "", false, nil
+	}
+
+	fileKey := key + options.Suffix
+
+	path, ok, err := source.Lookup(fileKey)
+	if err != nil || !ok {
+		return "", false, err
+	}
+
+	s, err := readValueFile(path, *options)
+	if err != nil {
+		return "", false, fmt.Errorf("environment variable %s is not present and %s can't be used: %w", key, fileKey, err)
+	}
+
+	return s, true, nil
+}
+
+func get(key string) (string, bool) {
+	return View{}.get(key)
+}
+
+// get is lookup for getters that don't return errors: failures are reported to the error handler and the variable is taken as not present
+func (v View) get(key string) (string, bool) {
+	s, ok, err := v.lookup(key)
+	if err != nil {
+		lookupMu.RLock()
+		handler := onLookupFailed
+		lookupMu.RUnlock()
+
+		handler(key, err)
+
+		return "", false
+	}
+
+	return s, ok
+}
+
+// resolveValue decrypts ENC[...] values and resolves secret references, marking their keys as sensitive.
+// Values are left as they are until a key provider, or a secret provider for the reference, is registered.
+func resolveValue(key, value string) (string, error) {
+	if IsSecretRef(value) && hasSecretProvider(value) {
+		s, err := ResolveSecretRef(value)
+		if err != nil {
+			return "", fmt.Errorf("environment variable %s: %w", key, err)
+		}
+
+		MarkSensitive(key)
+
+		return s, nil
+	}
+
+	if !IsEncryptedValue(value) {
+		return value, nil
+	}
+
+	provider := activeKeyProvider()
+	if provider == nil {
+		return value, nil
+	}
+
+	plain, err := DecryptValue(key, value, provider)
+	if err != nil {
+		return "", fmt.Errorf("environment variable %s: %w", key, err)
+	}
+
+	MarkSensitive(key)
+
+	return plain, nil
+}
+
+func readValueFile(path string, options FileOptions) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+
+	defer func() {
+		_ = f.Close()
+	}()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	if !fi.Mode().IsRegular() {
+		return "", fmt.Errorf("%s is not a regular file", path)
+	}
+
+	if perm := fi.Mode().Perm(); perm&options.ForbiddenPerm != 0 {
+		return "", fmt.Errorf("%s has unsafe permissions %#o", path, perm)
+	}
+
+	if fi.Size() > options.MaxSize {
+		return "", fmt.Errorf("%s is larger than %d bytes", path, options.MaxSize)
+	}
+
+	b, err := io.ReadAll(io.LimitReader(f, options.MaxSize+1))
+	if err != nil {
+		return "", err
+	}
+
+	if int64(len(b)) > options.MaxSize {
+		return "", fmt.Errorf("%s is larger than %d bytes", path, options.MaxSize)
+	}
+
+	return strings.TrimSpace(string(b)), nil
+}
@@ -0,0 +1,138 @@
+package envisage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeSecretFile(t *testing.T, name, content string, perm os.FileMode) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+
+	if err := os.WriteFile(path, []byte(content), perm); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chmod(path, perm); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestFileIndirection(t *testing.T) {
+	EnableFileIndirection(FileOptions{MaxSize: 16})
+	defer DisableFileIndirection()
+
+	var reported []string
+
+	previous := SetErrorHandler(func(key string, err error) {
+		reported = append(reported, err.Error())
+	})
+	defer SetErrorHandler(previous)
+
+	type testCase struct {
+		title,
+		key,
+		content,
+		expected string
+		perm      os.FileMode
+		expectErr bool
+	}
+
+	tests := []testCase{
+		{
+			title:    "file contents are trimmed",
+			key:      "T_FILE_DB_PASSWORD",
+			content:  "hunter2\n",
+			perm:     0o400,
+			expected: "hunter2",
+		},
+		{
+			title:    "readable by everyone but not writable",
+			key:      "T_FILE_K8S_SECRET",
+			content:  "s3cr3t",
+			perm:     0o644,
+			expected: "s3cr3t",
+		},
+		{
+			title:     "world writable file",
+			key:       "T_FILE_WORLD_WRITABLE",
+			content:   "x",
+			perm:      0o666,
+			expected:  "default",
+			expectErr: true,
+		},
+		{
+			title:     "file too large",
+			key:       "T_FILE_TOO_LARGE",
+			content:   strings.Repeat("x", 17),
+			perm:      0o600,
+			expected:  "default",
+			expectErr: true,
+		},
+	}
+
+	for _, x := range tests {
+		t.Run(x.title, func(t *testing.T) {
+			reported = nil
+
+			t.Setenv(x.key+"_FILE", writeSecretFile(t, x.key, x.content, x.perm))
+
+			if got := String(x.key, "default"); got != x.expected {
+				t.Errorf("failed. expecting %s, got %s", x.expected, got)
+			}
+
+			_, _, err := Lookup(x.key)
+			if x.expectErr != (err != nil) {
+				t.Fatalf("failed. expecting error %t, got %v", x.expectErr, err)
+			}
+
+			if !x.expectErr {
+				return
+			}
+
+			if len(reported) != 1 {
+				t.Errorf("failed. expecting String to report 1 error, got %d", len(reported))
+			}
+
+			for _, key := range []string{x.key, x.key + "_FILE"} {
+				if !strings.Contains(err.Error(), key) {
+					t.Errorf("failed. expecting %s in error message %q", key, err)
+				}
+			}
+		})
+	}
+}
+
+func TestFileIndirectionPrecedence(t *testing.T) {
+	EnableFileIndirection(FileOptions{})
+	defer DisableFileIndirection()
+
+	t.Setenv("T_FILE_BOTH", "from environment")
+	t.Setenv("T_FILE_BOTH_FILE", writeSecretFile(t, "both", "from file", 0o600))
+	t.Setenv("T_FILE_MISSING_FILE", filepath.Join(t.TempDir(), "does-not-exist"))
+
+	if got := String("T_FILE_BOTH", ""); got != "from environment" {
+		t.Errorf("failed. expecting variable to win over file, got %s", got)
+	}
+
+	if _, err := IntS("T_FILE_MISSING", ",", nil); err == nil {
+		t.Error("failed. expecting error for missing file")
+	}
+
+	if err := CheckAll(Rule{Key: "T_FILE_MISSING"}); err == nil {
+		t.Error("failed. expecting CheckAll to report missing file")
+	}
+}
+
+func TestFileIndirectionDisabled(t *testing.T) {
+	t.Setenv("T_FILE_DISABLED_FILE", writeSecretFile(t, "disabled", "x", 0o600))
+
+	if IsThere("T_FILE_DISABLED") {
+		t.Error("failed. expecting file indirection to be disabled by default")
+	}
+}
@@ -0,0 +1,235 @@
+package envisage
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MapValue lists the value types supported by MapOf and SetMapOf
+type MapValue interface {
+	string | int | int64 | float64 | bool | time.Duration
+}
+
+// Map returns the env var value as map[string]string.
+// The value is a list of pairs separated by pairSeparator, each pair holding a key and a value separated by keyValueSeparator,
+// like LABELS=team=core,tier=1 read with Map("LABELS", ",", "=", nil).
+// Keys are percent-decoded, as required by OTEL_RESOURCE_ATTRIBUTES, and surrounding spaces are trimmed.
+// It returns the default value only if the variable is not present.
+// A malformed pair or a duplicate key returns the default value and an error.
+func Map(key, pairSeparator, keyValueSeparator string, defaultValue map[string]string) (map[string]string, error) {
+	return MapOf(key, pairSeparator, keyValueSeparator, defaultValue)
+}
+
+// Map returns the env var value as map[string]string
+func (v View) Map(key, pairSeparator, keyValueSeparator string, defaultValue map[string]string) (map[string]string, error) {
+	return mapOf(v, v.Key(key), pairSeparator, keyValueSeparator, defaultValue)
+}
+
+// MapOf returns the env var value as a map of typed values, like map[string]int or map[string]time.Duration.
+// It follows the same rules as Map, and also fails if any value can't be converted to T.
+func MapOf[T MapValue](key, pairSeparator, keyValueSeparator string, defaultValue map[string]T) (map[string]T, error) {
+	return mapOf(View{}, key, pairSeparator, keyValueSeparator, defaultValue)
+}
+
+// mapOf is MapOf reading the full key from the view source, as methods can't have type parameters
+func mapOf[T MapValue](v View, key, pairSeparator, keyValueSeparator string, defaultValue map[string]T) (map[string]T, error) {
+	s, ok, err := v.lookup(key)
+	if err != nil || !ok {
+		return defaultValue, err
+	}
+
+	pairs, err := splitPairs(key, s, pairSeparator, keyValueSeparator)
+	if err != nil {
+		return defaultValue, err
+	}
+
+	m := make(map[string]T, len(pairs))
+
+	for _, pair := range pairs {
+		t, err := parseValue[T](pair[1])
+		if err != nil {
+			return defaultValue, fmt.Errorf("environment variable %s has invalid value for map key %q: %w", key, pair[0], err)
+		}
+
+		m[pair[0]] = t
+	}
+
+	return m, nil
+}
+
+// splitPairs returns the decoded keys and trimmed values of a map value, in order
+func splitPairs(key, s, pairSeparator, keyValueSeparator string) ([][2]string, error) {
+	if pairSeparator == "" || keyValueSeparator == "" {
+		return nil, fmt.Errorf("environment variable %s: map separators can't be empty", key)
+	}
+
+	var (
+		pairs [][2]string
+		seen  = make(map[string]struct{})
+	)
+
+	if strings.TrimSpace(s) == "" {
+		return pairs, nil
+	}
+
+	for _, pair := range strings.Split(s, pairSeparator) {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+
+		rawKey, v, found := strings.Cut(pair, keyValueSeparator)
+		if !found {
+			return nil, fmt.Errorf("environment variable %s has malformed map pair %q", key, pair)
+		}
+
+		k, err := url.PathUnescape(strings.TrimSpace(rawKey))
+		if err != nil {
+			return nil, fmt.Errorf("environment variable %s has malformed map key %q: %w", key, rawKey, err)
+		}
+
+		if k == "" {
+			return nil, fmt.Errorf("environment variable %s has empty map key in pair %q", key, pair)
+		}
+
+		if _, dup := seen[k]; dup {
+			return nil, fmt.Errorf("environment variable %s has duplicate map key %q", key, k)
+		}
+
+		seen[k] = struct{}{}
+		pairs = append(pairs, [2]string{k, strings.TrimSpace(v)})
+	}
+
+	return pairs, nil
+}
+
+// SetMap sets the value of the environment variable named by the key, in the format read by Map.
+// Pairs are sorted by key, so the output is deterministic.
+// Keys containing a separator or a percent sign are percent-encoded.
+// Empty keys, and values holding the pair separator or surrounding spaces, can't be read back, and return an error.
+func SetMap(key, pairSeparator, keyValueSeparator string, value map[string]string) error {
+	return SetMapOf(key, pairSeparator, keyValueSeparator, value)
+}
+
+// SetMap sets the value of the environment variable named by the key, as pairs sorted by key
+func (v View) SetMap(key, pairSeparator, keyValueSeparator string, value map[string]string) error {
+	return setMapOf(v, v.Key(key), pairSeparator, keyValueSeparator, value)
+}
+
+// SetMapOf sets the value of the environment variable named by the key, in the format read by MapOf.
+// Values are formatted the same way SetInt, SetF64, SetBool and time.Duration.String do.
+func SetMapOf[T MapValue](key, pairSeparator, keyValueSeparator string, value map[string]T) error {
+	return setMapOf(View{}, key, pairSeparator, keyValueSeparator, value)
+}
+
+// setMapOf is SetMapOf writing the full key to the view source
+func setMapOf[T MapValue](v View, key, pairSeparator, keyValueSeparator string, value map[string]T) error {
+	if pairSeparator == "" || keyValueSeparator == "" {
+		return fmt.Errorf("environment variable %s: map separators can't be empty", key)
+	}
+
+	keys := make([]string, 0, len(value))
+	for k := range value {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+
+	for _, k := range keys {
+		if k == "" {
+			return fmt.Errorf("environment variable %s: map key can't be empty", key)
+		}
+
+		s := formatValue(value[k])
+
+		if strings.Contains(s, pairSeparator) || s != strings.TrimSpace(s) {
+			return fmt.Errorf("environment variable %s: value of map key %q can't contain separator %q or surrounding spaces", key, k, pairSeparator)
+		}
+
+		pairs = append(pairs, escapeMapKey(k, pairSeparator, keyValueSeparator)+keyValueSeparator+s)
+	}
+
+	return v.set(key, strings.Join(pairs, pairSeparator))
+}
+
+func escapeMapKey(k string, separators ...string) string {
+	special := "% " + strings.Join(separators, "")
+
+	if !strings.ContainsAny(k, special) && k == strings.TrimSpace(k) {
+		return k
+	}
+
+	var sb strings.Builder
+
+	for i := 0; i < len(k); {
+		r, size := utf8.DecodeRuneInString(k[i:])
+
+		// spaces are escaped, whatever the alphabet, as splitPairs trims them
+		if strings.ContainsAny(k[i:i+size], special) || r < 0x20 || unicode.IsSpace(r) {
+			for j := i; j < i+size; j++ {
+				fmt.Fprintf(&sb, "%%%02X", k[j])
+			}
+		} else {
+			sb.WriteString(k[i : i+size])
+		}
+
+		i += size
+	}
+
+	return sb.String()
+}
+
+func parseValue[T MapValue](s string) (T, error) {
+	var (
+		zero T
+		v    any
+		err  error
+	)
+
+	switch any(zero).(type) {
+	case string:
+		v = s
+	case int:
+		v, err = strconv.Atoi(s)
+	case int64:
+		v, err = strconv.ParseInt(s, 10, 64)
+	case float64:
+		v, err = strconv.ParseFloat(s, 64)
+	case bool:
+		v, err = strconv.ParseBool(s)
+	case time.Duration:
+		v, err = time.ParseDuration(s)
+	}
+
+	if err != nil {
+		return zero, err
+	}
+
+	return v.(T), nil
+}
+
+func formatValue[T MapValue](value T) string {
+	switch v := any(value).(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Duration:
+		return v.String()
+	}
+
+	return fmt.Sprint(value)
+}
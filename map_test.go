@@ -0,0 +1,232 @@
+package envisage
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+func TestMap(t *testing.T) {
+	type testCase struct {
+		title,
+		key,
+		value string
+		expected  map[string]string
+		expectErr bool
+	}
+
+	tests := []testCase{
+		{
+			title:    "simple pairs",
+			key:      "T_MAP_SIMPLE",
+			value:    "team=core,tier=1",
+			expected: map[string]string{"team": "core", "tier": "1"},
+		},
+		{
+			title:    "spaces and empty value",
+			key:      "T_MAP_SPACES",
+			value:    " team = core , empty= ,",
+			expected: map[string]string{"team": "core", "empty": ""},
+		},
+		{
+			title:    "value containing the key value separator",
+			key:      "T_MAP_VALUE_WITH_SEPARATOR",
+			value:    "query=a=b",
+			expected: map[string]string{"query": "a=b"},
+		},
+		{
+			title:    "percent-encoded key",
+			key:      "T_MAP_ENCODED_KEY",
+			value:    "service%2Cname=api,host%3Dname=x",
+			expected: map[string]string{"service,name": "api", "host=name": "x"},
+		},
+		{
+			title:    "empty value",
+			key:      "T_MAP_EMPTY",
+			value:    "",
+			expected: map[string]string{},
+		},
+		{
+			title:     "duplicate key",
+			key:       "T_MAP_DUPLICATE",
+			value:     "a=1,b=2,a=3",
+			expectErr: true,
+		},
+		{
+			title:     "pair without separator",
+			key:       "T_MAP_MALFORMED",
+			value:     "a=1,b",
+			expectErr: true,
+		},
+		{
+			title:     "bad percent encoding",
+			key:       "T_MAP_BAD_ENCODING",
+			value:     "a%zz=1",
+			expectErr: true,
+		},
+	}
+
+	for _, x := range tests {
+		t.Run(x.title, func(t *testing.T) {
+			t.Setenv(x.key, x.value)
+
+			got, err := Map(x.key, ",", "=", nil)
+			if x.expectErr {
+				if err == nil {
+					t.Errorf("failed. expecting error, got %#v", got)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(x.expected, got) {
+				t.Errorf("failed. expecting %#v, got %#v", x.expected, got)
+			}
+		})
+	}
+}
+
+func TestMapOf(t *testing.T) {
+	t.Setenv("T_MAP_OF_INT", "a=1;b=-2")
+	t.Setenv("T_MAP_OF_DURATION", "/users:1s;/reports:1m30s")
+	t.Setenv("T_MAP_OF_INVALID", "a:x")
+
+	ints, err := MapOf[int]("T_MAP_OF_INT", ";", "=", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := map[string]int{"a": 1, "b": -2}; !reflect.DeepEqual(expected, ints) {
+		t.Errorf("failed. expecting %#v, got %#v", expected, ints)
+	}
+
+	durations, err := MapOf[time.Duration]("T_MAP_OF_DURATION", ";", ":", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := map[string]time.Duration{"/users": time.Second, "/reports": 90 * time.Second}; !reflect.DeepEqual(expected, durations) {
+		t.Errorf("failed. expecting %#v, got %#v", expected, durations)
+	}
+
+	defaultValue := map[string]float64{"x": 1}
+
+	floats, err := MapOf("T_MAP_OF_INVALID", ",", ":", defaultValue)
+	if err == nil {
+		t.Errorf("failed. expecting error, got %#v", floats)
+	}
+
+	if !reflect.DeepEqual(defaultValue, floats) {
+		t.Errorf("failed. expecting default value %#v, got %#v", defaultValue, floats)
+	}
+}
+
+func TestSetMap(t *testing.T) {
+	const key = "T_SET_MAP"
+
+	t.Setenv(key, "")
+
+	value := map[string]string{"zeta": "1", "alpha": "2", "with,comma": "3", "with=equal": "4", "100%": "5", "\u202fspaced": "6"}
+
+	if err := SetMap(key, ",", "=", value); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected, got := "100%25=5,alpha=2,with%2Ccomma=3,with%3Dequal=4,zeta=1,%E2%80%AFspaced=6", Get(key); expected != got {
+		t.Errorf("failed. expecting %s, got %s", expected, got)
+	}
+
+	got, err := Map(key, ",", "=", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(value, got) {
+		t.Errorf("failed. expecting %#v, got %#v", value, got)
+	}
+}
+
+func TestSetMapOf(t *testing.T) {
+	const key = "T_SET_MAP_OF"
+
+	t.Setenv(key, "")
+
+	value := map[string]time.Duration{"read": 5 * time.Second, "write": 1500 * time.Millisecond}
+
+	if err := SetMapOf(key, ",", "=", value); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected, got := "read=5s,write=1.5s", Get(key); expected != got {
+		t.Errorf("failed. expecting %s, got %s", expected, got)
+	}
+
+	got, err := MapOf[time.Duration](key, ",", "=", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(value, got) {
+		t.Errorf("failed. expecting %#v, got %#v", value, got)
+	}
+}
+
+func TestSetMapOfRoundTrip(t *testing.T) {
+	t.Setenv("T_ROUNDTRIP_MAP", "")
+
+	property := func(value map[string]int64) bool {
+		for k := range value {
+			if strings.TrimSpace(k) == "" || strings.ContainsRune(k, 0) {
+				delete(value, k)
+			}
+		}
+
+		if err := SetMapOf("T_ROUNDTRIP_MAP", ",", "=", value); err != nil {
+			return false
+		}
+
+		got, err := MapOf[int64]("T_ROUNDTRIP_MAP", ",", "=", nil)
+
+		return err == nil && len(got) == len(value) && (len(value) == 0 || reflect.DeepEqual(value, got))
+	}
+
+	if err := quick.Check(property, &quick.Config{Rand: rand.New(rand.NewSource(1))}); err != nil {
+		t.Errorf("failed. MapOf doesn't round trip: %v", err)
+	}
+
+	strs := func(value map[string]string) bool {
+		readable := true
+
+		for k, v := range value {
+			if k == "" || strings.Contains(v, ",") || v != strings.TrimSpace(v) || strings.ContainsRune(v, 0) {
+				readable = false
+			}
+		}
+
+		err := SetMap("T_ROUNDTRIP_MAP", ",", "=", value)
+		if !readable {
+			return err != nil
+		}
+
+		got, err := Map("T_ROUNDTRIP_MAP", ",", "=", nil)
+
+		return err == nil && len(got) == len(value) && (len(value) == 0 || reflect.DeepEqual(value, got))
+	}
+
+	if err := quick.Check(strs, &quick.Config{Rand: rand.New(rand.NewSource(1))}); err != nil {
+		t.Errorf("failed. Map doesn't round trip: %v", err)
+	}
+
+	for _, value := range []map[string]string{{"a": " x "}, {"": "1"}} {
+		if err := SetMap("T_ROUNDTRIP_MAP", ",", "=", value); err == nil {
+			t.Errorf("failed. expecting error setting %q, which can't be read back", value)
+		}
+	}
+}
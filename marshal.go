@@ -0,0 +1,300 @@
+package envisage
+
+import (
+	"encoding"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// Marshal returns the fields of the struct v as KEY=VALUE pairs, ready for exec.Cmd.Env, in field order.
+// It's the reverse of Bind: keys, nested prefixes, indexed slices and named maps follow the same rules, so Bind reads back
+// what Marshal writes. Values are formatted the way the Set functions do, like SetF64 and SetBool.
+// SecretString values are revealed and their keys marked as sensitive. Nil pointers, slices and maps are skipped.
+func Marshal(v any) ([]string, error) {
+	return marshalEnviron("", v)
+}
+
+// Apply sets the fields of the struct v on environment, as formatted by Marshal, with SetPairs, so either all are set or none
+func Apply(v any) error {
+	return View{}.Apply(v)
+}
+
+// WriteDotEnv writes the fields of the struct v in the format read by LoadFromFile, as formatted by Marshal.
+// Keys and values LoadFromFile can't read back are reported as errors: keys must start with a letter and hold at least
+// two letters, digits or underscores, and values can't hold line breaks, tabs, form feeds or trailing spaces.
+func WriteDotEnv(w io.Writer, v any) error {
+	return writeDotEnv(w, "", v)
+}
+
+// Marshal returns the fields of the struct x as KEY=VALUE pairs, as the package Marshal does, with every key prefixed
+func (v View) Marshal(x any) ([]string, error) {
+	return marshalEnviron(v.prefix, x)
+}
+
+// Apply sets the fields of the struct x on environment, as the package Apply does, with every key prefixed
+func (v View) Apply(x any) error {
+	pairs, err := marshal(v.prefix, x)
+	if err != nil {
+		return err
+	}
+
+	updates := make([]Pair, 0, len(pairs))
+
+	for _, pair := range pairs {
+		updates = append(updates, Pair{Key: pair[0], Value: pair[1]})
+	}
+
+	return View{source: v.source}.SetPairs(updates...)
+}
+
+// WriteDotEnv writes the fields of the struct x as a .env file, as the package WriteDotEnv does, with every key prefixed
+func (v View) WriteDotEnv(w io.Writer, x any) error {
+	return writeDotEnv(w, v.prefix, x)
+}
+
+func marshalEnviron(prefix string, v any) ([]string, error) {
+	pairs, err := marshal(prefix, v)
+	if err != nil {
+		return nil, err
+	}
+
+	environ := make([]string, 0, len(pairs))
+
+	for _, pair := range pairs {
+		environ = append(environ, pair[0]+"="+pair[1])
+	}
+
+	return environ, nil
+}
+
+func writeDotEnv(w io.Writer, prefix string, v any) error {
+	pairs, err := marshal(prefix, v)
+	if err != nil {
+		return err
+	}
+
+	for _, pair := range pairs {
+		if !rxConfig.MatchString(pair[0] + "=") {
+			return fmt.Errorf("environment variable %s: name can't be read back from a .env file", pair[0])
+		}
+
+		if strings.ContainsAny(pair[1], "\r\n\t\f") || pair[1] != strings.TrimRightFunc(pair[1], unicode.IsSpace) {
+			return fmt.Errorf("environment variable %s: value with line breaks, tabs, form feeds or trailing spaces can't be written to a .env file", pair[0])
+		}
+
+		if _, err := fmt.Fprintf(w, "%s=%s\n", pair[0], pair[1]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func marshal(prefix string, v any) ([][2]string, error) {
+	rv := reflect.ValueOf(v)
+
+	if rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("marshal source must be a struct or a non-nil pointer to struct, got %T", v)
+	}
+
+	m := &marshaler{}
+
+	if err := m.marshalStruct(prefix, rv); err != nil {
+		return nil, err
+	}
+
+	return m.pairs, nil
+}
+
+type marshaler struct {
+	pairs [][2]string
+}
+
+func (m *marshaler) marshalStruct(prefix string, v reflect.Value) error {
+	for _, f := range structFields(v.Type()) {
+		fv := v.Field(f.index)
+		key := prefix + f.key
+
+		var err error
+
+		switch {
+		case !isSection(fv.Type()):
+			err = m.marshalValue(key, f, fv)
+		case f.inline:
+			err = m.marshalSection(prefix, fv)
+		default:
+			err = m.marshalSection(key+"_", fv)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *marshaler) marshalSection(prefix string, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Struct:
+		return m.marshalStruct(prefix, v)
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+
+		return m.marshalStruct(prefix, v.Elem())
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := m.marshalSection(prefix+strconv.Itoa(i)+"_", v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("environment variable %s: unsupported map key type %s", strings.TrimSuffix(prefix, "_"), v.Type().Key())
+		}
+
+		names := make([]string, 0, v.Len())
+
+		for _, k := range v.MapKeys() {
+			names = append(names, k.String())
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			if name == "" || strings.Contains(name, "_") {
+				return fmt.Errorf("environment variable %s: map key %q can't be empty or contain underscores", strings.TrimSuffix(prefix, "_"), name)
+			}
+
+			if err := m.marshalSection(prefix+name+"_", v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m *marshaler) marshalValue(key string, f structField, v reflect.Value) error {
+	if (v.Kind() == reflect.Pointer || v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.IsNil() {
+		return nil
+	}
+
+	if v.Type() == secretType {
+		MarkSensitive(key)
+	}
+
+	s, err := formatField(key, f, v)
+	if err != nil {
+		return err
+	}
+
+	m.pairs = append(m.pairs, [2]string{key, s})
+
+	return nil
+}
+
+// formatField formats v as read back by setValue
+func formatField(key string, f structField, v reflect.Value) (string, error) {
+	t := v.Type()
+
+	switch {
+	case t == secretType:
+		return v.Interface().(SecretString).Reveal(), nil
+	case t == durationType:
+		return v.Interface().(fmt.Stringer).String(), nil
+	case t == reflect.PointerTo(urlType):
+		return v.Interface().(fmt.Stringer).String(), nil
+	case t.Implements(textMarshalerType), v.CanAddr() && reflect.PointerTo(t).Implements(textMarshalerType):
+		if !t.Implements(textMarshalerType) {
+			v = v.Addr()
+		}
+
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return "", fmt.Errorf("environment variable %s: %w", key, err)
+		}
+
+		return string(b), nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, t.Bits()), nil
+	case reflect.Pointer:
+		return formatField(key, f, v.Elem())
+	case reflect.Slice:
+		items := make([]string, 0, v.Len())
+
+		for i := 0; i < v.Len(); i++ {
+			s, err := formatField(key, f, v.Index(i))
+			if err != nil {
+				return "", err
+			}
+
+			if strings.Contains(s, f.sep) {
+				return "", fmt.Errorf("environment variable %s: item %d can't contain separator %q", key, i, f.sep)
+			}
+
+			items = append(items, s)
+		}
+
+		if len(items) == 1 && items[0] == "" {
+			return "", fmt.Errorf("environment variable %s: a single empty item is read back as an empty list", key)
+		}
+
+		return strings.Join(items, f.sep), nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return "", fmt.Errorf("environment variable %s: unsupported map key type %s", key, t.Key())
+		}
+
+		keys := make([]string, 0, v.Len())
+
+		for _, k := range v.MapKeys() {
+			keys = append(keys, k.String())
+		}
+
+		sort.Strings(keys)
+
+		pairs := make([]string, 0, len(keys))
+
+		for _, k := range keys {
+			s, err := formatField(key, f, v.MapIndex(reflect.ValueOf(k).Convert(t.Key())))
+			if err != nil {
+				return "", err
+			}
+
+			if strings.Contains(s, f.sep) || s != strings.TrimSpace(s) {
+				return "", fmt.Errorf("environment variable %s: value of map key %q can't contain separator %q or surrounding spaces", key, k, f.sep)
+			}
+
+			pairs = append(pairs, escapeMapKey(k, f.sep, f.kvSep)+f.kvSep+s)
+		}
+
+		return strings.Join(pairs, f.sep), nil
+	}
+
+	return "", fmt.Errorf("environment variable %s: unsupported field type %s", key, t)
+}
@@ -0,0 +1,172 @@
+package envisage
+
+import (
+	"bytes"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testMarshalConfig() testBindConfig {
+	c := testBindConfig{
+		testBindCommon: testBindCommon{LogLevel: "debug"},
+		Name:           "billing",
+		Debug:          true,
+		Timeout:        1500 * time.Millisecond,
+		Ratio:          0.1,
+		Ports:          []int{80, 443},
+		Labels:         map[string]string{"team": "core", "cost center": "42"},
+		Addr:           netip.MustParseAddr("::1"),
+		Ignored:        "never written",
+		Upstreams:      []testBindUpstream{{URL: "http://a", Weight: 1}, {URL: "http://b", Weight: 3}},
+		Tenants:        map[string]testBindTenant{"ACME": {Plan: "pro", Seats: 10}},
+	}
+
+	c.DB.Primary = testBindDatabase{Host: "primary.local", Port: 5432, Password: NewSecret("hunter2")}
+
+	return c
+}
+
+func TestMarshal(t *testing.T) {
+	environ, err := WithPrefix("T_MARSHAL_").Marshal(testMarshalConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"T_MARSHAL_LOG_LEVEL=debug",
+		"T_MARSHAL_APP_NAME=billing",
+		"T_MARSHAL_DEBUG=true",
+		"T_MARSHAL_TIMEOUT=1.5s",
+		"T_MARSHAL_RATIO=0.1",
+		"T_MARSHAL_PORTS=80;443",
+		"T_MARSHAL_LABELS=cost%20center=42,team=core",
+		"T_MARSHAL_ADDR=::1",
+		"T_MARSHAL_DB_PRIMARY_HOST=primary.local",
+		"T_MARSHAL_DB_PRIMARY_PORT=5432",
+		"T_MARSHAL_DB_PRIMARY_PASSWORD=hunter2",
+		"T_MARSHAL_UPSTREAMS_0_URL=http://a",
+		"T_MARSHAL_UPSTREAMS_0_WEIGHT=1",
+		"T_MARSHAL_UPSTREAMS_1_URL=http://b",
+		"T_MARSHAL_UPSTREAMS_1_WEIGHT=3",
+		"T_MARSHAL_TENANTS_ACME_PLAN=pro",
+		"T_MARSHAL_TENANTS_ACME_SEATS=10",
+	}
+
+	if !reflect.DeepEqual(expected, environ) {
+		t.Errorf("failed. expecting %v, got %v", expected, environ)
+	}
+
+	if !IsSensitive("T_MARSHAL_DB_PRIMARY_PASSWORD") {
+		t.Error("failed. expecting SecretString field to mark its key as sensitive")
+	}
+}
+
+func TestApplyRoundTrip(t *testing.T) {
+	c := testMarshalConfig()
+	view := WithPrefix("T_APPLY_")
+
+	environ, err := view.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, kv := range environ {
+		k, _, _ := strings.Cut(kv, "=")
+		t.Setenv(k, "") // restored when the test ends
+	}
+
+	if err := view.Apply(c); err != nil {
+		t.Fatal(err)
+	}
+
+	var got testBindConfig
+
+	if err := view.Bind(&got); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Ignored = ""
+
+	if !reflect.DeepEqual(c, got) {
+		t.Errorf("failed. expecting %+v, got %+v", c, got)
+	}
+}
+
+func TestWriteDotEnv(t *testing.T) {
+	var buf bytes.Buffer
+
+	if err := WithPrefix("T_DOTENV_").WriteDotEnv(&buf, testMarshalConfig()); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "child.env")
+
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := LoadFromFile(path, false, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := m["T_DOTENV_DB_PRIMARY_PASSWORD"]; got != "hunter2" {
+		t.Errorf("failed. expecting hunter2, got %s", got)
+	}
+
+	if got := m["T_DOTENV_LABELS"]; got != "cost%20center=42,team=core" {
+		t.Errorf("failed. expecting escaped map, got %s", got)
+	}
+
+	c := testMarshalConfig()
+	c.Name = "multi\nline"
+
+	if err := WriteDotEnv(&bytes.Buffer{}, c); err == nil {
+		t.Error("failed. expecting error writing a line break")
+	}
+
+	c.Name = "form\ffeed"
+
+	if err := WriteDotEnv(&bytes.Buffer{}, c); err == nil {
+		t.Error("failed. expecting error writing a form feed")
+	}
+
+	tenants := struct {
+		Tenants map[string]struct{ URL string }
+	}{Tenants: map[string]struct{ URL string }{"acme-corp": {URL: "x"}}}
+
+	if err := WriteDotEnv(&bytes.Buffer{}, tenants); err == nil || !strings.Contains(err.Error(), "TENANTS_acme-corp_URL") {
+		t.Errorf("failed. expecting error naming the unreadable key, got %v", err)
+	}
+
+	if err := WriteDotEnv(&bytes.Buffer{}, struct{ A int }{A: 1}); err == nil {
+		t.Error("failed. expecting error writing a single letter key")
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	type testCase struct {
+		title string
+		value any
+	}
+
+	tests := []testCase{
+		{title: "not a struct", value: 42},
+		{title: "nil pointer", value: (*testBindConfig)(nil)},
+		{title: "item holding separator", value: struct{ Hosts []string }{Hosts: []string{"a,b"}}},
+		{title: "map name with underscore", value: struct{ Tenants map[string]testBindTenant }{Tenants: map[string]testBindTenant{"A_B": {}}}},
+	}
+
+	for _, x := range tests {
+		t.Run(x.title, func(t *testing.T) {
+			if _, err := Marshal(x.value); err == nil {
+				t.Error("failed. expecting error")
+			}
+		})
+	}
+}
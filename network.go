@@ -0,0 +1,238 @@
+package envisage
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// IP returns the env var value as netip.Addr, accepting IPv4 and IPv6 addresses like 10.0.0.1 or ::1
+// It returns the default value only if the variable is not present. An invalid address returns the default value and an error.
+func IP(key string, defaultValue netip.Addr) (netip.Addr, error) {
+	return View{}.IP(key, defaultValue)
+}
+
+// IP returns the env var value as netip.Addr
+func (v View) IP(key string, defaultValue netip.Addr) (netip.Addr, error) {
+	v, key = v.at(key)
+
+	s, ok, err := v.lookup(key)
+	if err != nil || !ok {
+		return defaultValue, err
+	}
+
+	a, err := netip.ParseAddr(strings.TrimSpace(s))
+	if err != nil {
+		return defaultValue, fmt.Errorf("environment variable %s: %w", key, err)
+	}
+
+	return a, nil
+}
+
+// Prefix returns the env var value as netip.Prefix, like 10.0.0.0/8
+// A bare address is accepted as a single-host prefix, so 10.0.0.1 yields 10.0.0.1/32.
+// It returns the default value only if the variable is not present. An invalid prefix returns the default value and an error.
+func Prefix(key string, defaultValue netip.Prefix) (netip.Prefix, error) {
+	return View{}.Prefix(key, defaultValue)
+}
+
+// Prefix returns the env var value as netip.Prefix
+func (v View) Prefix(key string, defaultValue netip.Prefix) (netip.Prefix, error) {
+	v, key = v.at(key)
+
+	s, ok, err := v.lookup(key)
+	if err != nil || !ok {
+		return defaultValue, err
+	}
+
+	p, err := parsePrefix(s)
+	if err != nil {
+		return defaultValue, fmt.Errorf("environment variable %s: %w", key, err)
+	}
+
+	return p, nil
+}
+
+// PrefixS returns the env var value as []netip.Prefix, like trusted proxies 10.0.0.0/8,192.168.1.10
+// Items are parsed as in Prefix. Spaces around items and empty items are ignored.
+// It returns the default value only if the variable is not present. An invalid item returns the default value and an error.
+func PrefixS(key, listItemSeparator string, defaultValue []netip.Prefix) ([]netip.Prefix, error) {
+	return View{}.PrefixS(key, listItemSeparator, defaultValue)
+}
+
+// PrefixS returns the env var value as []netip.Prefix
+func (v View) PrefixS(key, listItemSeparator string, defaultValue []netip.Prefix) ([]netip.Prefix, error) {
+	v, key = v.at(key)
+
+	s, ok, err := v.lookup(key)
+	if err != nil || !ok {
+		return defaultValue, err
+	}
+
+	a := []netip.Prefix{}
+
+	for _, x := range strings.Split(s, listItemSeparator) {
+		if strings.TrimSpace(x) == "" {
+			continue
+		}
+
+		p, err := parsePrefix(x)
+		if err != nil {
+			return defaultValue, fmt.Errorf("environment variable %s: %w", key, err)
+		}
+
+		a = append(a, p)
+	}
+
+	return a, nil
+}
+
+// PrefixSlice returns the env var value as []netip.Prefix
+// It's an idiomatic convenience alias for PrefixS
+func PrefixSlice(key, listItemSeparator string, defaultValue []netip.Prefix) ([]netip.Prefix, error) {
+	return View{}.PrefixSlice(key, listItemSeparator, defaultValue)
+}
+
+// PrefixSlice returns the env var value as []netip.Prefix
+// It's an idiomatic convenience alias for PrefixS
+func (v View) PrefixSlice(key, listItemSeparator string, defaultValue []netip.Prefix) ([]netip.Prefix, error) {
+	return v.PrefixS(key, listItemSeparator, defaultValue)
+}
+
+// HostPort returns the env var value as a host:port address, suitable for net.Listen and net.Dial.
+// If the value has no port, defaultPort is added, so "localhost" yields "localhost:8080" and "::1" yields "[::1]:8080".
+// An empty host is kept, so ":8080" binds every interface.
+// It returns the default value only if the variable is not present.
+// A port out of the 1-65535 range returns the default value and an error.
+func HostPort(key string, defaultPort int, defaultValue string) (string, error) {
+	return View{}.HostPort(key, defaultPort, defaultValue)
+}
+
+// HostPort returns the env var value as host:port
+func (v View) HostPort(key string, defaultPort int, defaultValue string) (string, error) {
+	v, key = v.at(key)
+
+	s, ok, err := v.lookup(key)
+	if err != nil || !ok {
+		return defaultValue, err
+	}
+
+	hp, err := parseHostPort(strings.TrimSpace(s), defaultPort)
+	if err != nil {
+		return defaultValue, fmt.Errorf("environment variable %s: %w", key, err)
+	}
+
+	return hp, nil
+}
+
+func parsePrefix(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+
+	if !strings.Contains(s, "/") {
+		a, err := netip.ParseAddr(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+
+		return netip.PrefixFrom(a, a.BitLen()), nil
+	}
+
+	return netip.ParsePrefix(s)
+}
+
+func parseHostPort(s string, defaultPort int) (string, error) {
+	host, port := s, ""
+
+	switch a, err := netip.ParseAddr(strings.Trim(s, "[]")); {
+	case err == nil:
+		// a bare IPv6 address can't be told apart from host:port by SplitHostPort
+		host = a.String()
+	case strings.Contains(s, ":"):
+		if host, port, err = net.SplitHostPort(s); err != nil {
+			return "", err
+		}
+	}
+
+	if port == "" {
+		port = strconv.Itoa(defaultPort)
+	}
+
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		return "", fmt.Errorf("invalid port %q", port)
+	}
+
+	if p < 1 || p > 65535 {
+		return "", fmt.Errorf("port %d out of range 1-65535", p)
+	}
+
+	return net.JoinHostPort(host, strconv.Itoa(p)), nil
+}
+
+// SetIP sets the value of the environment variable named by the key, in the format read by IP
+func SetIP(key string, value netip.Addr) error {
+	return View{}.SetIP(key, value)
+}
+
+// SetIP sets the value of the environment variable named by the key, in the format read by IP
+func (v View) SetIP(key string, value netip.Addr) error {
+	v, key = v.at(key)
+
+	if !value.IsValid() {
+		return fmt.Errorf("environment variable %s: invalid address", key)
+	}
+
+	return v.set(key, value.String())
+}
+
+// SetPrefix sets the value of the environment variable named by the key, in the format read by Prefix
+func SetPrefix(key string, value netip.Prefix) error {
+	return View{}.SetPrefix(key, value)
+}
+
+// SetPrefix sets the value of the environment variable named by the key, in the format read by Prefix
+func (v View) SetPrefix(key string, value netip.Prefix) error {
+	v, key = v.at(key)
+
+	if !value.IsValid() {
+		return fmt.Errorf("environment variable %s: invalid prefix", key)
+	}
+
+	return v.set(key, value.String())
+}
+
+// SetPrefixS sets the value of the environment variable named by the key, in the format read by PrefixS
+func SetPrefixS(key, listItemSeparator string, value []netip.Prefix) error {
+	return View{}.SetPrefixS(key, listItemSeparator, value)
+}
+
+// SetPrefixS sets the value of the environment variable named by the key, in the format read by PrefixS
+func (v View) SetPrefixS(key, listItemSeparator string, value []netip.Prefix) error {
+	v, key = v.at(key)
+
+	items := make([]string, 0, len(value))
+
+	for i, p := range value {
+		if !p.IsValid() {
+			return fmt.Errorf("environment variable %s: item %d is an invalid prefix", key, i)
+		}
+
+		items = append(items, p.String())
+	}
+
+	return v.SetStringS(key, listItemSeparator, items)
+}
+
+// SetPrefixSlice sets the value of the environment variable named by the key, in the format read by PrefixS
+// It's an idiomatic convenience alias for SetPrefixS
+func SetPrefixSlice(key, listItemSeparator string, value []netip.Prefix) error {
+	return View{}.SetPrefixSlice(key, listItemSeparator, value)
+}
+
+// SetPrefixSlice sets the value of the environment variable named by the key, in the format read by PrefixS
+// It's an idiomatic convenience alias for SetPrefixS
+func (v View) SetPrefixSlice(key, listItemSeparator string, value []netip.Prefix) error {
+	return v.SetPrefixS(key, listItemSeparator, value)
+}
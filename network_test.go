@@ -0,0 +1,261 @@
+package envisage
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+	"testing/quick"
+)
+
+func TestIP(t *testing.T) {
+	type testCase struct {
+		title,
+		key,
+		value string
+		expected  netip.Addr
+		expectErr bool
+	}
+
+	defaultValue := netip.MustParseAddr("127.0.0.1")
+
+	tests := []testCase{
+		{
+			title:    "ipv4",
+			key:      "T_IP_V4",
+			value:    "10.0.0.1",
+			expected: netip.MustParseAddr("10.0.0.1"),
+		},
+		{
+			title:    "ipv6",
+			key:      "T_IP_V6",
+			value:    " ::1 ",
+			expected: netip.MustParseAddr("::1"),
+		},
+		{
+			title:     "invalid",
+			key:       "T_IP_INVALID",
+			value:     "10.0.0.256",
+			expected:  defaultValue,
+			expectErr: true,
+		},
+	}
+
+	for _, x := range tests {
+		t.Run(x.title, func(t *testing.T) {
+			t.Setenv(x.key, x.value)
+
+			got, err := IP(x.key, defaultValue)
+			if x.expectErr != (err != nil) {
+				t.Errorf("failed. expecting error %t, got %v", x.expectErr, err)
+			}
+
+			if got != x.expected {
+				t.Errorf("failed. expecting %s, got %s", x.expected, got)
+			}
+		})
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	type testCase struct {
+		title,
+		key,
+		value string
+		expected  netip.Prefix
+		expectErr bool
+	}
+
+	tests := []testCase{
+		{
+			title:    "cidr",
+			key:      "T_PREFIX_CIDR",
+			value:    "10.0.0.0/8",
+			expected: netip.MustParsePrefix("10.0.0.0/8"),
+		},
+		{
+			title:    "bare ipv4 address",
+			key:      "T_PREFIX_BARE_V4",
+			value:    "192.168.1.10",
+			expected: netip.MustParsePrefix("192.168.1.10/32"),
+		},
+		{
+			title:    "bare ipv6 address",
+			key:      "T_PREFIX_BARE_V6",
+			value:    "fd00::1",
+			expected: netip.MustParsePrefix("fd00::1/128"),
+		},
+		{
+			title:     "invalid bits",
+			key:       "T_PREFIX_INVALID",
+			value:     "10.0.0.0/33",
+			expectErr: true,
+		},
+	}
+
+	for _, x := range tests {
+		t.Run(x.title, func(t *testing.T) {
+			t.Setenv(x.key, x.value)
+
+			got, err := Prefix(x.key, netip.Prefix{})
+			if x.expectErr != (err != nil) {
+				t.Errorf("failed. expecting error %t, got %v", x.expectErr, err)
+			}
+
+			if got != x.expected {
+				t.Errorf("failed. expecting %s, got %s", x.expected, got)
+			}
+		})
+	}
+}
+
+func TestPrefixS(t *testing.T) {
+	t.Setenv("T_PREFIXES", "10.0.0.0/8, 172.16.0.0/12,,192.168.1.10")
+	t.Setenv("T_PREFIXES_INVALID", "10.0.0.0/8,proxy")
+
+	got, err := PrefixS("T_PREFIXES", ",", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("172.16.0.0/12"),
+		netip.MustParsePrefix("192.168.1.10/32"),
+	}
+
+	if !reflect.DeepEqual(expected, got) {
+		t.Errorf("failed. expecting %v, got %v", expected, got)
+	}
+
+	defaultValue := []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}
+
+	got, err = PrefixSlice("T_PREFIXES_INVALID", ",", defaultValue)
+	if err == nil {
+		t.Error("failed. expecting error")
+	}
+
+	if !reflect.DeepEqual(defaultValue, got) {
+		t.Errorf("failed. expecting default value %v, got %v", defaultValue, got)
+	}
+}
+
+func TestHostPort(t *testing.T) {
+	type testCase struct {
+		title,
+		key,
+		value,
+		expected string
+		expectErr bool
+	}
+
+	const defaultValue = "localhost:80"
+
+	tests := []testCase{
+		{
+			title:    "host and port",
+			key:      "T_HOSTPORT_FULL",
+			value:    "api.example.com:9090",
+			expected: "api.example.com:9090",
+		},
+		{
+			title:    "host only",
+			key:      "T_HOSTPORT_HOST",
+			value:    "api.example.com",
+			expected: "api.example.com:8080",
+		},
+		{
+			title:    "port only",
+			key:      "T_HOSTPORT_PORT",
+			value:    ":9090",
+			expected: ":9090",
+		},
+		{
+			title:    "bare ipv6",
+			key:      "T_HOSTPORT_IPV6",
+			value:    "::1",
+			expected: "[::1]:8080",
+		},
+		{
+			title:    "bracketed ipv6 with port",
+			key:      "T_HOSTPORT_IPV6_PORT",
+			value:    "[::1]:9090",
+			expected: "[::1]:9090",
+		},
+		{
+			title:     "port out of range",
+			key:       "T_HOSTPORT_RANGE",
+			value:     "localhost:70000",
+			expected:  defaultValue,
+			expectErr: true,
+		},
+		{
+			title:     "port not numeric",
+			key:       "T_HOSTPORT_NOT_NUMERIC",
+			value:     "localhost:http",
+			expected:  defaultValue,
+			expectErr: true,
+		},
+	}
+
+	for _, x := range tests {
+		t.Run(x.title, func(t *testing.T) {
+			t.Setenv(x.key, x.value)
+
+			got, err := HostPort(x.key, 8080, defaultValue)
+			if x.expectErr != (err != nil) {
+				t.Errorf("failed. expecting error %t, got %v", x.expectErr, err)
+			}
+
+			if got != x.expected {
+				t.Errorf("failed. expecting %s, got %s", x.expected, got)
+			}
+		})
+	}
+}
+
+func TestSetNetworkRoundTrip(t *testing.T) {
+	t.Setenv("T_ROUNDTRIP_IP", "")
+
+	addr := func(b [16]byte, v4 bool) netip.Addr {
+		if v4 {
+			return netip.AddrFrom4([4]byte{b[0], b[1], b[2], b[3]})
+		}
+
+		return netip.AddrFrom16(b)
+	}
+
+	ip := func(b [16]byte, v4 bool) bool {
+		value := addr(b, v4)
+
+		if err := SetIP("T_ROUNDTRIP_IP", value); err != nil {
+			return false
+		}
+
+		got, err := IP("T_ROUNDTRIP_IP", netip.Addr{})
+
+		return err == nil && got == value
+	}
+
+	prefixS := func(b [16]byte, v4 bool, bits uint8) bool {
+		a := addr(b, v4)
+		value := []netip.Prefix{netip.PrefixFrom(a, int(bits)%(a.BitLen()+1)).Masked(), netip.PrefixFrom(a, a.BitLen())}
+
+		if err := SetPrefixS("T_ROUNDTRIP_IP", ",", value); err != nil {
+			return false
+		}
+
+		got, err := PrefixS("T_ROUNDTRIP_IP", ",", nil)
+
+		return err == nil && reflect.DeepEqual(value, got)
+	}
+
+	for name, property := range map[string]any{"IP": ip, "PrefixS": prefixS} {
+		if err := quick.Check(property, nil); err != nil {
+			t.Errorf("failed. %s doesn't round trip: %v", name, err)
+		}
+	}
+
+	if err := SetIP("T_ROUNDTRIP_IP", netip.Addr{}); err == nil {
+		t.Error("failed. expecting error for the zero address")
+	}
+}
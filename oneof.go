@@ -0,0 +1,81 @@
+package envisage
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Constraint validates the value of an environment variable, as used by Check
+type Constraint func(key, value string) error
+
+// OneOf returns the env var value as string, restricted to the allowed values.
+// It returns the default value if either the variable is not present or if its value isn't exactly one of the allowed values.
+func OneOf(key string, allowed []string, defaultValue string) string {
+	return View{}.OneOf(key, allowed, defaultValue)
+}
+
+// OneOf returns the env var value if it is one of the allowed values
+func (v View) OneOf(key string, allowed []string, defaultValue string) string {
+	v, key = v.at(key)
+
+	if s, ok := v.get(key); ok {
+		if value, found := matchAllowed(s, allowed, false); found {
+			return value
+		}
+	}
+
+	return defaultValue
+}
+
+// ParseOneOf returns the env var value as string, restricted to the allowed values.
+// If foldCase is true the value is compared ignoring case, and the allowed spelling is returned.
+// It returns the default value only if the variable is not present.
+// A value that isn't allowed returns the default value and an error listing the allowed values.
+func ParseOneOf(key string, allowed []string, foldCase bool, defaultValue string) (string, error) {
+	return View{}.ParseOneOf(key, allowed, foldCase, defaultValue)
+}
+
+// ParseOneOf returns the env var value if it is one of the allowed values, or an error
+func (v View) ParseOneOf(key string, allowed []string, foldCase bool, defaultValue string) (string, error) {
+	v, key = v.at(key)
+
+	s, ok, err := v.lookup(key)
+	if err != nil || !ok {
+		return defaultValue, err
+	}
+
+	value, err := checkAllowed(key, s, allowed, foldCase)
+	if err != nil {
+		return defaultValue, err
+	}
+
+	return value, nil
+}
+
+// AllowedValues returns a Constraint that accepts only the given values.
+// If foldCase is true the value is compared ignoring case.
+func AllowedValues(allowed []string, foldCase bool) Constraint {
+	return func(key, value string) error {
+		_, err := checkAllowed(key, value, allowed, foldCase)
+
+		return err
+	}
+}
+
+func checkAllowed(key, value string, allowed []string, foldCase bool) (string, error) {
+	if v, found := matchAllowed(value, allowed, foldCase); found {
+		return v, nil
+	}
+
+	return "", fmt.Errorf("environment variable %s has value %q, allowed values are %s", key, mask(key, value), strings.Join(allowed, ", "))
+}
+
+func matchAllowed(value string, allowed []string, foldCase bool) (string, bool) {
+	for _, a := range allowed {
+		if a == value || (foldCase && strings.EqualFold(a, value)) {
+			return a, true
+		}
+	}
+
+	return "", false
+}
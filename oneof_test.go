@@ -0,0 +1,125 @@
+package envisage
+
+import (
+	"strings"
+	"testing"
+)
+
+var logLevels = []string{"debug", "info", "warn"}
+
+func TestOneOf(t *testing.T) {
+	type testCase struct {
+		title,
+		key,
+		value,
+		expected string
+	}
+
+	tests := []testCase{
+		{
+			title:    "allowed value",
+			key:      "T_ONEOF_ALLOWED",
+			value:    "warn",
+			expected: "warn",
+		},
+		{
+			title:    "value not allowed",
+			key:      "T_ONEOF_NOT_ALLOWED",
+			value:    "verbose",
+			expected: "info",
+		},
+		{
+			title:    "case mismatch",
+			key:      "T_ONEOF_CASE_MISMATCH",
+			value:    "DEBUG",
+			expected: "info",
+		},
+	}
+
+	for _, x := range tests {
+		t.Run(x.title, func(t *testing.T) {
+			t.Setenv(x.key, x.value)
+
+			if got := OneOf(x.key, logLevels, "info"); got != x.expected {
+				t.Errorf("failed. expecting %s, got %s", x.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseOneOf(t *testing.T) {
+	type testCase struct {
+		title,
+		key,
+		value string
+		foldCase  bool
+		expected  string
+		expectErr bool
+	}
+
+	tests := []testCase{
+		{
+			title:    "allowed value",
+			key:      "T_PARSE_ONEOF_ALLOWED",
+			value:    "debug",
+			expected: "debug",
+		},
+		{
+			title:    "folded case returns allowed spelling",
+			key:      "T_PARSE_ONEOF_FOLD",
+			value:    "WaRn",
+			foldCase: true,
+			expected: "warn",
+		},
+		{
+			title:     "case mismatch without folding",
+			key:       "T_PARSE_ONEOF_NO_FOLD",
+			value:     "WARN",
+			expected:  "info",
+			expectErr: true,
+		},
+		{
+			title:     "value not allowed",
+			key:       "T_PARSE_ONEOF_NOT_ALLOWED",
+			value:     "verbose",
+			expected:  "info",
+			expectErr: true,
+		},
+	}
+
+	for _, x := range tests {
+		t.Run(x.title, func(t *testing.T) {
+			t.Setenv(x.key, x.value)
+
+			got, err := ParseOneOf(x.key, logLevels, x.foldCase, "info")
+			if x.expectErr != (err != nil) {
+				t.Errorf("failed. expecting error %t, got %v", x.expectErr, err)
+			}
+
+			if err != nil && !strings.Contains(err.Error(), "debug, info, warn") {
+				t.Errorf("failed. expecting allowed values in error, got %v", err)
+			}
+
+			if got != x.expected {
+				t.Errorf("failed. expecting %s, got %s", x.expected, got)
+			}
+		})
+	}
+}
+
+func TestCheckWithAllowedValues(t *testing.T) {
+	t.Setenv("T_CHECK_ONEOF_OK", "Info")
+	t.Setenv("T_CHECK_ONEOF_BAD", "verbose")
+
+	if err := Check("T_CHECK_ONEOF_OK", "", false, false, AllowedValues(logLevels, true)); err != nil {
+		t.Errorf("failed. expecting no error, got %v", err)
+	}
+
+	if err := Check("T_CHECK_ONEOF_BAD", "", false, false, AllowedValues(logLevels, true)); err == nil {
+		t.Error("failed. expecting error for value not allowed")
+	}
+
+	if err := Check("T_CHECK_ONEOF_MISSING", "debug", false, false, AllowedValues(logLevels, false)); err != nil {
+		t.Errorf("failed. expecting default value to satisfy constraint, got %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+//go:build linux
+
+package envisage
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// permissionProblems returns why the file at path could be read, or edited, by someone other than the current user
+func permissionProblems(path string) ([]string, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+
+	switch perm := fi.Mode().Perm(); {
+	case perm&0o044 == 0o044:
+		problems = append(problems, fmt.Sprintf("readable by group and others (mode %#o)", perm))
+	case perm&0o040 != 0:
+		problems = append(problems, fmt.Sprintf("readable by group (mode %#o)", perm))
+	case perm&0o004 != 0:
+		problems = append(problems, fmt.Sprintf("readable by others (mode %#o)", perm))
+	}
+
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		if uid := int(st.Uid); uid != 0 && uid != os.Getuid() {
+			problems = append(problems, fmt.Sprintf("owned by uid %d, not by the current user", uid))
+		}
+	}
+
+	return problems, nil
+}
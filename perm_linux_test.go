@@ -0,0 +1,97 @@
+//go:build linux
+
+package envisage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadPermissions(t *testing.T) {
+	MarkSensitive("T_PERM_PASSWORD")
+
+	keyring, keys := testKeyring(t, 1)
+	t.Setenv(KeyEnv, keys[0])
+
+	encryptedValue, err := EncryptValue("T_PERM_PASSWORD", "hunter2", keyring)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type testCase struct {
+		title,
+		content string
+		perm        os.FileMode
+		expectWarn  bool
+		expectError bool
+	}
+
+	tests := []testCase{
+		{
+			title:       "sensitive key readable by others",
+			content:     "T_PERM_HOST=db\nT_PERM_PASSWORD=hunter2\n",
+			perm:        0o644,
+			expectWarn:  true,
+			expectError: true,
+		},
+		{
+			title:       "sensitive key readable by group",
+			content:     "T_PERM_PASSWORD=hunter2\n",
+			perm:        0o640,
+			expectWarn:  true,
+			expectError: true,
+		},
+		{
+			title:   "sensitive key private to owner",
+			content: "T_PERM_PASSWORD=hunter2\n",
+			perm:    0o600,
+		},
+		{
+			title:   "no sensitive keys",
+			content: "T_PERM_HOST=db\n",
+			perm:    0o644,
+		},
+		{
+			title:   "sensitive key encrypted",
+			content: "T_PERM_PASSWORD=" + encryptedValue + "\n",
+			perm:    0o644,
+		},
+	}
+
+	for _, x := range tests {
+		t.Run(x.title, func(t *testing.T) {
+			path := writeSecretFile(t, "app.env", x.content, x.perm)
+
+			result, err := Load(path, LoadOptions{Permissions: PermissionsWarn})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if x.expectWarn != (len(result.Warnings) == 1) {
+				t.Fatalf("failed. expecting warning %t, got %v", x.expectWarn, result.Warnings)
+			}
+
+			if x.expectWarn && (!strings.Contains(result.Warnings[0], "T_PERM_PASSWORD") || strings.Contains(result.Warnings[0], "hunter2")) {
+				t.Errorf("failed. expecting warning to name the key but not its value, got %s", result.Warnings[0])
+			}
+
+			_, err = Load(path, LoadOptions{Permissions: PermissionsError})
+			if x.expectError != errors.Is(err, ErrUnsafePermissions) {
+				t.Errorf("failed. expecting ErrUnsafePermissions %t, got %v", x.expectError, err)
+			}
+		})
+	}
+
+	path := filepath.Join(t.TempDir(), "app.env")
+
+	if err := os.WriteFile(path, []byte("T_PERM_PASSWORD=hunter2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(path, LoadOptions{}); err != nil {
+		t.Errorf("failed. expecting permissions to be ignored by default, got %v", err)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package envisage
+
+// permissionProblems is only implemented on Linux, where file modes and owners are checked
+func permissionProblems(string) ([]string, error) {
+	return nil, nil
+}
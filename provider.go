@@ -0,0 +1,223 @@
+package envisage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const secretRefScheme = "secret"
+
+const maxSecretSize = 1 << 20
+
+// SecretProvider resolves secret references, like secret://vault/db/prod#password, registered by RegisterSecretProvider.
+// path is the reference path without the leading slash, db/prod in the example, and field its fragment, password in the example.
+type SecretProvider interface {
+	Resolve(path, field string) (string, error)
+}
+
+type cachedSecret struct {
+	value   string
+	expires time.Time
+}
+
+type registeredProvider struct {
+	provider SecretProvider
+	ttl      time.Duration
+}
+
+var (
+	providersMu   sync.Mutex
+	providers     = map[string]registeredProvider{}
+	secretsCache  = map[string]cachedSecret{}
+	providerClock = time.Now
+)
+
+// RegisterSecretProvider registers the provider resolving values like secret://<name>/<path>#<field>.
+// Values are resolved by the getters and LoadFromFile when read, and their keys are marked as sensitive.
+// References to names with no provider registered are read as they are.
+// Resolved values are cached for ttl; a zero ttl disables caching. A nil provider unregisters name.
+func RegisterSecretProvider(name string, provider SecretProvider, ttl time.Duration) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+
+	for ref := range secretsCache {
+		if u, err := url.Parse(ref); err == nil && u.Host == name {
+			delete(secretsCache, ref)
+		}
+	}
+
+	if provider == nil {
+		delete(providers, name)
+		return
+	}
+
+	providers[name] = registeredProvider{provider: provider, ttl: ttl}
+}
+
+// IsSecretRef returns true if value is a secret reference, like secret://vault/db/prod#password
+func IsSecretRef(value string) bool {
+	return strings.HasPrefix(value, secretRefScheme+"://")
+}
+
+// hasSecretProvider returns true if a provider is registered for the name ref points at
+func hasSecretProvider(ref string) bool {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return false
+	}
+
+	providersMu.Lock()
+	defer providersMu.Unlock()
+
+	_, ok := providers[u.Host]
+
+	return ok
+}
+
+// ResolveSecretRef returns the value a secret reference points at, using the registered providers
+func ResolveSecretRef(ref string) (string, error) {
+	u, err := url.Parse(ref)
+	if err != nil || u.Scheme != secretRefScheme || u.Host == "" {
+		return "", errors.New("secret reference must look like secret://<provider>/<path>#<field>")
+	}
+
+	providersMu.Lock()
+	p, ok := providers[u.Host]
+	cached, hit := secretsCache[ref]
+	providersMu.Unlock()
+
+	if !ok {
+		return "", fmt.Errorf("no secret provider registered for %q", u.Host)
+	}
+
+	if hit && providerClock().Before(cached.expires) {
+		return cached.value, nil
+	}
+
+	s, err := p.provider.Resolve(strings.TrimPrefix(u.Path, "/"), u.Fragment)
+	if err != nil {
+		return "", fmt.Errorf("secret provider %s: %w", u.Host, err)
+	}
+
+	if p.ttl > 0 {
+		providersMu.Lock()
+		secretsCache[ref] = cachedSecret{value: s, expires: providerClock().Add(p.ttl)}
+		providersMu.Unlock()
+	}
+
+	return s, nil
+}
+
+// FileSecretProvider resolves references to files under Root, so secret://files/db/prod reads Root/db/prod.
+// With a field, the file must hold a JSON object, and the field value is returned.
+type FileSecretProvider struct {
+	Root string
+}
+
+// Resolve implements SecretProvider
+func (p FileSecretProvider) Resolve(name, field string) (string, error) {
+	clean := path.Clean("/" + name)
+	if clean == "/" {
+		return "", errors.New("empty secret path")
+	}
+
+	f, err := os.Open(filepath.Join(p.Root, filepath.FromSlash(clean)))
+	if err != nil {
+		return "", err
+	}
+
+	defer func() {
+		_ = f.Close()
+	}()
+
+	return secretValue(f, field)
+}
+
+// HTTPSecretProvider resolves references with a GET request to BaseURL followed by the path,
+// so secret://http/db/prod reads BaseURL/db/prod. Header is added to every request, like an authorization token.
+// With a field, the response must hold a JSON object, and the field value is returned.
+type HTTPSecretProvider struct {
+	BaseURL string
+	Header  http.Header
+	// Client defaults to an http.Client with a 10 seconds timeout
+	Client *http.Client
+}
+
+// Resolve implements SecretProvider
+func (p HTTPSecretProvider) Resolve(name, field string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(p.BaseURL, "/")+"/"+strings.TrimPrefix(name, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+
+	for k, vs := range p.Header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+
+	client := p.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GET %s: %s", req.URL.Path, resp.Status)
+	}
+
+	return secretValue(resp.Body, field)
+}
+
+func secretValue(r io.Reader, field string) (string, error) {
+	b, err := io.ReadAll(io.LimitReader(r, maxSecretSize+1))
+	if err != nil {
+		return "", err
+	}
+
+	if len(b) > maxSecretSize {
+		return "", fmt.Errorf("secret is larger than %d bytes", maxSecretSize)
+	}
+
+	if field == "" {
+		return strings.TrimSpace(string(b)), nil
+	}
+
+	var m map[string]json.RawMessage
+
+	if err := json.Unmarshal(b, &m); err != nil {
+		return "", fmt.Errorf("secret with field %s must be a JSON object: %w", field, err)
+	}
+
+	raw, ok := m[field]
+	if !ok {
+		return "", fmt.Errorf("secret has no field %s", field)
+	}
+
+	var s string
+
+	if err := json.Unmarshal(raw, &s); err != nil {
+		// numbers and booleans are returned as written
+		return string(raw), nil
+	}
+
+	return s, nil
+}
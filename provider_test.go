@@ -0,0 +1,179 @@
+package envisage
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFileSecretProvider(t *testing.T) {
+	root := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(root, "db"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "db", "prod"), []byte(`{"user":"app","password":"hunter2","port":5432}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "token"), []byte("abc123\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	RegisterSecretProvider("files", FileSecretProvider{Root: root}, 0)
+	defer RegisterSecretProvider("files", nil, 0)
+
+	type testCase struct {
+		title,
+		ref,
+		expected string
+		expectErr bool
+	}
+
+	tests := []testCase{
+		{title: "json field", ref: "secret://files/db/prod#password", expected: "hunter2"},
+		{title: "json number field", ref: "secret://files/db/prod#port", expected: "5432"},
+		{title: "whole file", ref: "secret://files/token", expected: "abc123"},
+		{title: "missing field", ref: "secret://files/db/prod#host", expectErr: true},
+		{title: "missing file", ref: "secret://files/db/staging", expectErr: true},
+		{title: "path traversal stays under root", ref: "secret://files/../../etc/passwd", expectErr: true},
+		{title: "unknown provider reads as it is", ref: "secret://vault/db/prod#password", expected: "secret://vault/db/prod#password"},
+	}
+
+	for _, x := range tests {
+		t.Run(x.title, func(t *testing.T) {
+			t.Setenv("T_SECRET_REF", x.ref)
+
+			got, ok, err := Lookup("T_SECRET_REF")
+			if x.expectErr {
+				if err == nil {
+					t.Errorf("failed. expecting error, got %s", got)
+				}
+
+				return
+			}
+
+			if err != nil || !ok {
+				t.Fatalf("failed. expecting value, got %t %v", ok, err)
+			}
+
+			if got != x.expected {
+				t.Errorf("failed. expecting %s, got %s", x.expected, got)
+			}
+		})
+	}
+
+	if !IsSensitive("T_SECRET_REF") {
+		t.Error("failed. expecting resolved keys to be marked sensitive")
+	}
+}
+
+func TestHTTPSecretProviderWithCache(t *testing.T) {
+	var requests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+
+		if r.Header.Get("Authorization") != "Bearer t0ken" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		if r.URL.Path != "/v1/db/prod" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"password":"hunter2"}`))
+	}))
+	defer server.Close()
+
+	now := time.Now()
+
+	providerClock = func() time.Time { return now }
+	defer func() { providerClock = time.Now }()
+
+	RegisterSecretProvider("vault", HTTPSecretProvider{
+		BaseURL: server.URL + "/v1/",
+		Header:  http.Header{"Authorization": []string{"Bearer t0ken"}},
+	}, time.Minute)
+	defer RegisterSecretProvider("vault", nil, 0)
+
+	t.Setenv("T_HTTP_SECRET", "secret://vault/db/prod#password")
+	t.Setenv("T_HTTP_SECRET_MISSING", "secret://vault/db/staging#password")
+
+	for i := 0; i < 3; i++ {
+		if got := Secret("T_HTTP_SECRET", "").Reveal(); got != "hunter2" {
+			t.Fatalf("failed. expecting hunter2, got %s", got)
+		}
+	}
+
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("failed. expecting 1 request thanks to the cache, got %d", got)
+	}
+
+	now = now.Add(2 * time.Minute)
+
+	if got := String("T_HTTP_SECRET", ""); got != "hunter2" {
+		t.Fatalf("failed. expecting hunter2, got %s", got)
+	}
+
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Errorf("failed. expecting a new request after the ttl, got %d", got)
+	}
+
+	if _, _, err := Lookup("T_HTTP_SECRET_MISSING"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("failed. expecting not found error, got %v", err)
+	}
+}
+
+func TestLoadFromFileResolvesSecretRefs(t *testing.T) {
+	root := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(root, "api"), []byte("abc123"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	RegisterSecretProvider("files", FileSecretProvider{Root: root}, 0)
+	defer RegisterSecretProvider("files", nil, 0)
+
+	path := filepath.Join(t.TempDir(), "refs.env")
+
+	if err := os.WriteFile(path, []byte("T_REF_API_TOKEN=secret://files/api\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := LoadFromFile(path, false, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := m["T_REF_API_TOKEN"]; got != "abc123" {
+		t.Errorf("failed. expecting abc123, got %s", got)
+	}
+}
+
+func TestSecretRefsReadAsTheyAreWithoutProvider(t *testing.T) {
+	t.Setenv("T_REF_UNREGISTERED", "secret://nowhere/db#password")
+
+	var failures []string
+
+	previous := SetErrorHandler(func(key string, err error) {
+		failures = append(failures, key)
+	})
+	defer SetErrorHandler(previous)
+
+	if got := String("T_REF_UNREGISTERED", "default"); got != "secret://nowhere/db#password" {
+		t.Errorf("failed. expecting the reference as it is, got %s", got)
+	}
+
+	if len(failures) > 0 || IsSensitive("T_REF_UNREGISTERED") {
+		t.Errorf("failed. expecting no errors and the key not marked, got %v", failures)
+	}
+}
@@ -0,0 +1,125 @@
+package envisage
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Relation is a rule spanning several environment variables, like RequiredIf or MutuallyExclusive.
+// Relations are passed to Validate and CheckAll along with rules, and are evaluated after them.
+// A variable counts as set when it is present and not empty.
+type Relation struct {
+	name  string
+	keys  []string
+	check func() (Failure, bool)
+}
+
+func (Relation) spec() {}
+
+// String returns the relation description, like "RequiredTogether(TLS_CERT_FILE, TLS_KEY_FILE)"
+func (r Relation) String() string {
+	return r.name + "(" + strings.Join(r.keys, ", ") + ")"
+}
+
+// RequiredIf requires every one of the required keys to be set when the variable named by key has the given value.
+// Values are compared ignoring case, and boolean values by meaning, so "1" matches "true".
+func RequiredIf(key, value string, required ...string) Relation {
+	keys := append([]string{key}, required...)
+
+	return Relation{
+		name: "RequiredIf",
+		keys: keys,
+		check: func() (Failure, bool) {
+			if s, ok := get(key); !ok || !sameValue(s, value) {
+				return Failure{}, false
+			}
+
+			if missing := unsetKeys(required); len(missing) > 0 {
+				return relationFailure(FailureMissing, keys, "%s required when %s=%s", strings.Join(missing, ", "), key, value)
+			}
+
+			return Failure{}, false
+		},
+	}
+}
+
+// MutuallyExclusive allows at most one of the keys to be set, like DATABASE_URL and DB_HOST
+func MutuallyExclusive(keys ...string) Relation {
+	return Relation{
+		name: "MutuallyExclusive",
+		keys: keys,
+		check: func() (Failure, bool) {
+			if set := setKeys(keys); len(set) > 1 {
+				return relationFailure(FailureConflict, keys, "only one can be set, found %s", strings.Join(set, ", "))
+			}
+
+			return Failure{}, false
+		},
+	}
+}
+
+// RequiredTogether requires the keys to be either all set or all unset, like TLS_CERT_FILE and TLS_KEY_FILE
+func RequiredTogether(keys ...string) Relation {
+	return Relation{
+		name: "RequiredTogether",
+		keys: keys,
+		check: func() (Failure, bool) {
+			if set := setKeys(keys); len(set) > 0 && len(set) < len(keys) {
+				return relationFailure(FailureMissing, keys, "must be set together, missing %s", strings.Join(unsetKeys(keys), ", "))
+			}
+
+			return Failure{}, false
+		},
+	}
+}
+
+func relationFailure(kind FailureKind, keys []string, format string, a ...interface{}) (Failure, bool) {
+	return Failure{
+		Key:  strings.Join(keys, ", "),
+		Keys: keys,
+		Kind: kind,
+		Err:  fmt.Errorf(format, a...),
+	}, true
+}
+
+func isSet(key string) bool {
+	s, ok := get(key)
+
+	return ok && s != ""
+}
+
+func setKeys(keys []string) []string {
+	var a []string
+
+	for _, k := range keys {
+		if isSet(k) {
+			a = append(a, k)
+		}
+	}
+
+	return a
+}
+
+func unsetKeys(keys []string) []string {
+	var a []string
+
+	for _, k := range keys {
+		if !isSet(k) {
+			a = append(a, k)
+		}
+	}
+
+	return a
+}
+
+func sameValue(a, b string) bool {
+	if strings.EqualFold(a, b) {
+		return true
+	}
+
+	ba, aerr := strconv.ParseBool(a)
+	bb, berr := strconv.ParseBool(b)
+
+	return aerr == nil && berr == nil && ba == bb
+}
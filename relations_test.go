@@ -0,0 +1,127 @@
+package envisage
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRelations(t *testing.T) {
+	type testCase struct {
+		title    string
+		env      map[string]string
+		relation Relation
+		expected error
+	}
+
+	tests := []testCase{
+		{
+			title:    "required if condition holds",
+			env:      map[string]string{"T_TLS_ENABLED": "true", "T_TLS_CERT_FILE": "cert.pem"},
+			relation: RequiredIf("T_TLS_ENABLED", "true", "T_TLS_CERT_FILE", "T_TLS_KEY_FILE"),
+			expected: ErrMissing,
+		},
+		{
+			title:    "required if condition holds with boolean spelling",
+			env:      map[string]string{"T_TLS_ENABLED": "1"},
+			relation: RequiredIf("T_TLS_ENABLED", "true", "T_TLS_CERT_FILE"),
+			expected: ErrMissing,
+		},
+		{
+			title:    "required if satisfied",
+			env:      map[string]string{"T_TLS_ENABLED": "TRUE", "T_TLS_CERT_FILE": "cert.pem", "T_TLS_KEY_FILE": "key.pem"},
+			relation: RequiredIf("T_TLS_ENABLED", "true", "T_TLS_CERT_FILE", "T_TLS_KEY_FILE"),
+		},
+		{
+			title:    "required if condition doesn't hold",
+			env:      map[string]string{"T_TLS_ENABLED": "false"},
+			relation: RequiredIf("T_TLS_ENABLED", "true", "T_TLS_CERT_FILE", "T_TLS_KEY_FILE"),
+		},
+		{
+			title:    "mutually exclusive conflict",
+			env:      map[string]string{"T_DATABASE_URL": "postgres://db/app", "T_DB_HOST": "db"},
+			relation: MutuallyExclusive("T_DATABASE_URL", "T_DB_HOST"),
+			expected: ErrConflict,
+		},
+		{
+			title:    "mutually exclusive with empty value",
+			env:      map[string]string{"T_DATABASE_URL": "postgres://db/app", "T_DB_HOST": ""},
+			relation: MutuallyExclusive("T_DATABASE_URL", "T_DB_HOST"),
+		},
+		{
+			title:    "required together partially set",
+			env:      map[string]string{"T_TLS_KEY_FILE": "key.pem"},
+			relation: RequiredTogether("T_TLS_CERT_FILE", "T_TLS_KEY_FILE"),
+			expected: ErrMissing,
+		},
+		{
+			title:    "required together none set",
+			relation: RequiredTogether("T_TLS_CERT_FILE", "T_TLS_KEY_FILE"),
+		},
+	}
+
+	for _, x := range tests {
+		t.Run(x.title, func(t *testing.T) {
+			for k, v := range x.env {
+				t.Setenv(k, v)
+			}
+
+			err := CheckAll(x.relation)
+
+			if x.expected == nil {
+				if err != nil {
+					t.Errorf("failed. expecting no error, got %v", err)
+				}
+
+				return
+			}
+
+			if !errors.Is(err, x.expected) {
+				t.Fatalf("failed. expecting %v, got %v", x.expected, err)
+			}
+
+			for _, key := range x.relation.keys {
+				if !strings.Contains(err.Error(), key) {
+					t.Errorf("failed. expecting %s in error message %q", key, err)
+				}
+			}
+		})
+	}
+}
+
+func TestRelationsRunAfterRules(t *testing.T) {
+	t.Setenv("T_REL_ORDER_ENABLED", "true")
+	t.Setenv("T_REL_ORDER_PORT", "")
+	_ = os.Unsetenv("T_REL_ORDER_PORT")
+
+	report := Validate(
+		RequiredIf("T_REL_ORDER_ENABLED", "true", "T_REL_ORDER_PORT"),
+		Rule{Key: "T_REL_ORDER_PORT", Type: TypeInt, Default: "8443", SetDefault: true},
+	)
+
+	if err := report.Err(); err != nil {
+		t.Errorf("failed. expecting relation to see the default set by the rule, got %v", err)
+	}
+}
+
+func TestValidatePointerSpecs(t *testing.T) {
+	t.Setenv("T_REL_PTR_A", "1")
+	t.Setenv("T_REL_PTR_B", "2")
+
+	exclusive := MutuallyExclusive("T_REL_PTR_A", "T_REL_PTR_B")
+
+	report := Validate(&Rule{Key: "T_REL_PTR_MISSING"}, &exclusive, Relation{}, (*Rule)(nil))
+
+	if len(report.Failures) != 2 {
+		t.Fatalf("failed. expecting the rule and relation pointers to fail, got %v", report.Failures)
+	}
+
+	if !errors.Is(report.Err(), ErrMissing) || !errors.Is(report.Err(), ErrConflict) {
+		t.Errorf("failed. expecting missing and conflict failures, got %v", report.Err())
+	}
+
+	if err := CheckAll(&Rule{Key: "T_REL_PTR_MISSING"}); err == nil {
+		t.Error("failed. expecting CheckAll to report the missing key")
+	}
+}
@@ -0,0 +1,446 @@
+package envisage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
+)
+
+// Type is the type an environment variable value must convert to, as declared in a Rule
+type Type int
+
+// Types supported by Rule
+const (
+	TypeString Type = iota
+	TypeInt
+	TypeInt64
+	TypeFloat64
+	TypeBool
+	TypeDuration
+	TypeURL
+)
+
+var typeNames = map[Type]string{
+	TypeString:   "string",
+	TypeInt:      "int",
+	TypeInt64:    "int64",
+	TypeFloat64:  "float64",
+	TypeBool:     "bool",
+	TypeDuration: "duration",
+	TypeURL:      "url",
+}
+
+// String returns the type name
+func (t Type) String() string {
+	if s, ok := typeNames[t]; ok {
+		return s
+	}
+
+	return "Type(" + strconv.Itoa(int(t)) + ")"
+}
+
+// Rule declares what a valid environment variable looks like.
+// Only Key is mandatory; zero values disable the corresponding check.
+type Rule struct {
+	// Key is the environment variable name
+	Key string
+	// Type the value must convert to. Defaults to TypeString.
+	Type Type
+	// Default is used when the variable is not present
+	Default string
+	// SetDefault updates the environment with Default when the variable is not present, like twoWay in Check
+	SetDefault bool
+	// CanBeEmpty accepts a missing or empty value. Other checks apply only to non-empty values.
+	CanBeEmpty bool
+	// Min and Max bound numeric and duration values, written the way the value itself is, like "1" or "500ms"
+	Min, Max string
+	// Pattern the value must match, with regexp.MatchString semantics
+	Pattern *regexp.Regexp
+	// MinLen and MaxLen bound the value length in characters. Zero means unbounded.
+	MinLen, MaxLen int
+	// Allowed lists the accepted values, compared ignoring case if FoldCase is true
+	Allowed  []string
+	FoldCase bool
+	// Constraints are applied after every other check
+	Constraints []Constraint
+}
+
+// FailureKind tells why a Rule wasn't satisfied
+type FailureKind int
+
+// Failure kinds reported by Validate
+const (
+	FailureInvalid FailureKind = iota
+	FailureMissing
+	FailureEmpty
+	FailureConflict
+)
+
+// String returns the failure kind name
+func (k FailureKind) String() string {
+	switch k {
+	case FailureMissing:
+		return "missing"
+	case FailureEmpty:
+		return "empty"
+	case FailureConflict:
+		return "conflict"
+	default:
+		return "invalid"
+	}
+}
+
+// Sentinel errors matched by errors.Is against a Failure, or against the error returned by CheckAll
+var (
+	ErrMissing  = errors.New("missing environment variable")
+	ErrEmpty    = errors.New("empty environment variable")
+	ErrInvalid  = errors.New("invalid environment variable")
+	ErrConflict = errors.New("conflicting environment variables")
+)
+
+// Failure describes a single environment variable that didn't satisfy its Rule, or the variables that didn't satisfy a Relation.
+// For relations, Key joins every key involved, which are also listed in Keys.
+type Failure struct {
+	Key   string
+	Keys  []string
+	Value string
+	Kind  FailureKind
+	Err   error
+}
+
+// Error implements the error interface. Values of sensitive keys are masked.
+func (f Failure) Error() string {
+	if len(f.Keys) > 0 {
+		return fmt.Sprintf("environment variables %s: %s", f.Key, f.detail())
+	}
+
+	switch f.Kind {
+	case FailureMissing:
+		return fmt.Sprintf("missing environment variable %s", f.Key)
+	case FailureEmpty:
+		return fmt.Sprintf("environment variable %s can't be empty", f.Key)
+	default:
+		return fmt.Sprintf("environment variable %s is invalid: %s", f.Key, f.detail())
+	}
+}
+
+// detail returns the error message with the value masked if the key is sensitive,
+// as errors wrapped from parsers, like strconv, and custom constraints may quote it
+func (f Failure) detail() string {
+	if f.Err == nil {
+		return "-"
+	}
+
+	s := f.Err.Error()
+
+	if masked := mask(f.Key, f.Value); masked != f.Value {
+		s = strings.ReplaceAll(s, strconv.Quote(f.Value), strconv.Quote(masked))
+		s = strings.ReplaceAll(s, f.Value, masked)
+	}
+
+	return s
+}
+
+// Unwrap returns the underlying error, if any
+func (f Failure) Unwrap() error {
+	return f.Err
+}
+
+// Is matches ErrMissing, ErrEmpty or ErrInvalid according to the failure kind
+func (f Failure) Is(target error) bool {
+	switch f.Kind {
+	case FailureMissing:
+		return target == ErrMissing
+	case FailureEmpty:
+		return target == ErrEmpty
+	case FailureConflict:
+		return target == ErrConflict
+	default:
+		return target == ErrInvalid
+	}
+}
+
+// Report aggregates the failures found by Validate
+type Report struct {
+	Failures []Failure
+}
+
+// OK returns true if no rule failed
+func (r *Report) OK() bool {
+	return len(r.Failures) == 0
+}
+
+// Err returns the report as an error, or nil if no rule failed
+func (r *Report) Err() error {
+	if r.OK() {
+		return nil
+	}
+
+	return r
+}
+
+// Error implements the error interface, listing every failure in a single line each
+func (r *Report) Error() string {
+	lines := make([]string, 0, len(r.Failures))
+
+	for _, f := range r.Failures {
+		lines = append(lines, f.Error())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Unwrap returns every failure, so errors.Is and errors.As inspect them the same way they do with errors.Join
+func (r *Report) Unwrap() []error {
+	errs := make([]error, 0, len(r.Failures))
+
+	for _, f := range r.Failures {
+		errs = append(errs, f)
+	}
+
+	return errs
+}
+
+// WriteTable writes the failures as an aligned, human-readable table, suitable for logs.
+// Values of sensitive keys are masked.
+func (r *Report) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	if _, err := fmt.Fprintln(tw, "KEY\tPROBLEM\tVALUE\tDETAIL"); err != nil {
+		return err
+	}
+
+	for _, f := range r.Failures {
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%q\t%s\n", f.Key, f.Kind, mask(f.Key, f.Value), f.detail()); err != nil {
+			return err
+		}
+	}
+
+	return tw.Flush()
+}
+
+// Table returns the failures rendered by WriteTable
+func (r *Report) Table() string {
+	var sb strings.Builder
+
+	_ = r.WriteTable(&sb)
+
+	return sb.String()
+}
+
+// Spec is anything Validate and CheckAll can evaluate: a Rule or a Relation, or a pointer to one
+type Spec interface {
+	spec()
+}
+
+func (Rule) spec() {}
+
+// Validate checks every spec in a single pass and reports all failures.
+// Rules are evaluated first, in order, followed by relations, so relations see the defaults set by rules with SetDefault.
+func Validate(specs ...Spec) *Report {
+	r := &Report{}
+
+	var (
+		rules     []Rule
+		relations []Relation
+	)
+
+	for _, s := range specs {
+		switch x := s.(type) {
+		case *Rule:
+			if x != nil {
+				rules = append(rules, *x)
+			}
+		case Rule:
+			rules = append(rules, x)
+		case *Relation:
+			if x != nil {
+				relations = append(relations, *x)
+			}
+		case Relation:
+			relations = append(relations, x)
+		}
+	}
+
+	for _, rule := range rules {
+		if f, failed := rule.check(); failed {
+			r.Failures = append(r.Failures, f)
+		}
+	}
+
+	for _, rel := range relations {
+		if rel.check == nil { // zero Relation
+			continue
+		}
+
+		if f, failed := rel.check(); failed {
+			r.Failures = append(r.Failures, f)
+		}
+	}
+
+	return r
+}
+
+func (rule Rule) check() (Failure, bool) {
+	fail := func(kind FailureKind, value string, err error) (Failure, bool) {
+		return Failure{Key: rule.Key, Value: value, Kind: kind, Err: err}, true
+	}
+
+	s, ok, err := lookup(rule.Key)
+	if err != nil {
+		return fail(FailureInvalid, "", err)
+	}
+
+	if !ok {
+		s = rule.Default
+
+		if rule.SetDefault {
+			if err := SetString(rule.Key, s); err != nil {
+				return fail(FailureInvalid, s, err)
+			}
+		}
+	}
+
+	if s == "" {
+		switch {
+		case rule.CanBeEmpty:
+			return Failure{}, false
+		case ok:
+			return fail(FailureEmpty, s, nil)
+		default:
+			return fail(FailureMissing, s, nil)
+		}
+	}
+
+	if err := rule.checkValue(s); err != nil {
+		return fail(FailureInvalid, s, err)
+	}
+
+	for _, c := range rule.Constraints {
+		if err := c(rule.Key, s); err != nil {
+			return fail(FailureInvalid, s, err)
+		}
+	}
+
+	return Failure{}, false
+}
+
+func (rule Rule) checkValue(s string) error {
+	n, err := parseTyped(rule.Type, s)
+	if err != nil {
+		// the parse error is left out on purpose, as it quotes the value
+		return fmt.Errorf("not a valid %s", rule.Type)
+	}
+
+	if rule.Min != "" || rule.Max != "" {
+		if err := rule.checkRange(n); err != nil {
+			return err
+		}
+	}
+
+	if rule.Pattern != nil && !rule.Pattern.MatchString(s) {
+		return fmt.Errorf("doesn't match pattern %s", rule.Pattern)
+	}
+
+	if l := utf8.RuneCountInString(s); l < rule.MinLen {
+		return fmt.Errorf("length %d below minimum %d", l, rule.MinLen)
+	} else if rule.MaxLen > 0 && l > rule.MaxLen {
+		return fmt.Errorf("length %d above maximum %d", l, rule.MaxLen)
+	}
+
+	if len(rule.Allowed) > 0 {
+		if _, found := matchAllowed(s, rule.Allowed, rule.FoldCase); !found {
+			return fmt.Errorf("allowed values are %s", strings.Join(rule.Allowed, ", "))
+		}
+	}
+
+	return nil
+}
+
+func (rule Rule) checkRange(n number) error {
+	switch rule.Type {
+	case TypeInt, TypeInt64, TypeFloat64, TypeDuration:
+	default:
+		return fmt.Errorf("range can't be checked for type %s", rule.Type)
+	}
+
+	if rule.Min != "" {
+		lo, err := parseTyped(rule.Type, rule.Min)
+		if err != nil {
+			return fmt.Errorf("invalid rule minimum %q: %w", rule.Min, err)
+		}
+
+		if n.less(lo) {
+			return fmt.Errorf("below minimum %s", rule.Min)
+		}
+	}
+
+	if rule.Max != "" {
+		hi, err := parseTyped(rule.Type, rule.Max)
+		if err != nil {
+			return fmt.Errorf("invalid rule maximum %q: %w", rule.Max, err)
+		}
+
+		if hi.less(n) {
+			return fmt.Errorf("above maximum %s", rule.Max)
+		}
+	}
+
+	return nil
+}
+
+// CheckAll evaluates every spec and returns nil if all of them are satisfied.
+// Otherwise it returns a *Report listing every missing, empty, malformed or conflicting key, instead of stopping at the first one like Check does.
+// The error can be inspected with errors.Is(err, ErrMissing) or errors.As(err, &report).
+func CheckAll(specs ...Spec) error {
+	return Validate(specs...).Err()
+}
+
+// number is a value parsed for range checks. Integers and durations are kept as int64, so they're compared exactly.
+type number struct {
+	i       int64
+	f       float64
+	isFloat bool
+}
+
+func (n number) less(other number) bool {
+	if n.isFloat {
+		return n.f < other.f
+	}
+
+	return n.i < other.i
+}
+
+// parseTyped checks s converts to t and returns its numeric value, used for range checks
+func parseTyped(t Type, s string) (number, error) {
+	switch t {
+	case TypeInt:
+		i, err := strconv.Atoi(s)
+		return number{i: int64(i)}, err
+	case TypeInt64:
+		i, err := strconv.ParseInt(s, 10, 64)
+		return number{i: i}, err
+	case TypeFloat64:
+		f, err := strconv.ParseFloat(s, 64)
+		return number{f: f, isFloat: true}, err
+	case TypeBool:
+		_, err := strconv.ParseBool(s)
+		return number{}, err
+	case TypeDuration:
+		d, err := time.ParseDuration(s)
+		return number{i: int64(d)}, err
+	case TypeURL:
+		_, err := parseURL(s, URLOptions{})
+		return number{}, err
+	case TypeString:
+		return number{}, nil
+	}
+
+	return number{}, fmt.Errorf("unknown type %s", t)
+}
@@ -0,0 +1,289 @@
+package envisage
+
+import (
+	"errors"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	type testCase struct {
+		title,
+		value string
+		present  bool
+		rule     Rule
+		expected *FailureKind
+	}
+
+	var (
+		invalid = func() *FailureKind { k := FailureInvalid; return &k }()
+		missing = func() *FailureKind { k := FailureMissing; return &k }()
+		empty   = func() *FailureKind { k := FailureEmpty; return &k }()
+	)
+
+	tests := []testCase{
+		{
+			title:   "valid int in range",
+			value:   "8080",
+			present: true,
+			rule:    Rule{Type: TypeInt, Min: "1", Max: "65535"},
+		},
+		{
+			title:    "int64 just above a maximum beyond float64 precision",
+			value:    "9007199254740993",
+			present:  true,
+			rule:     Rule{Type: TypeInt64, Max: "9007199254740992"},
+			expected: invalid,
+		},
+		{
+			title:    "duration just below minimum",
+			value:    "9223372036854775806ns",
+			present:  true,
+			rule:     Rule{Type: TypeDuration, Min: "9223372036854775807ns"},
+			expected: invalid,
+		},
+		{
+			title:    "int above maximum",
+			value:    "70000",
+			present:  true,
+			rule:     Rule{Type: TypeInt, Min: "1", Max: "65535"},
+			expected: invalid,
+		},
+		{
+			title:    "not an int",
+			value:    "eighty",
+			present:  true,
+			rule:     Rule{Type: TypeInt},
+			expected: invalid,
+		},
+		{
+			title:   "duration in range",
+			value:   "250ms",
+			present: true,
+			rule:    Rule{Type: TypeDuration, Min: "100ms", Max: "1s"},
+		},
+		{
+			title:    "duration below minimum",
+			value:    "10ms",
+			present:  true,
+			rule:     Rule{Type: TypeDuration, Min: "100ms"},
+			expected: invalid,
+		},
+		{
+			title:    "invalid bool",
+			value:    "yes please",
+			present:  true,
+			rule:     Rule{Type: TypeBool},
+			expected: invalid,
+		},
+		{
+			title:   "valid url",
+			value:   "postgres://db:5432/app",
+			present: true,
+			rule:    Rule{Type: TypeURL},
+		},
+		{
+			title:    "url without scheme",
+			value:    "//db:5432/app",
+			present:  true,
+			rule:     Rule{Type: TypeURL},
+			expected: invalid,
+		},
+		{
+			title:    "pattern mismatch",
+			value:    "abc-123",
+			present:  true,
+			rule:     Rule{Pattern: regexp.MustCompile(`^[a-z]+$`)},
+			expected: invalid,
+		},
+		{
+			title:    "too short",
+			value:    "abc",
+			present:  true,
+			rule:     Rule{MinLen: 4},
+			expected: invalid,
+		},
+		{
+			title:    "too long",
+			value:    "abcdef",
+			present:  true,
+			rule:     Rule{MaxLen: 5},
+			expected: invalid,
+		},
+		{
+			title:   "allowed value ignoring case",
+			value:   "WARN",
+			present: true,
+			rule:    Rule{Allowed: logLevels, FoldCase: true},
+		},
+		{
+			title:    "value not allowed",
+			value:    "verbose",
+			present:  true,
+			rule:     Rule{Allowed: logLevels},
+			expected: invalid,
+		},
+		{
+			title:    "missing",
+			rule:     Rule{},
+			expected: missing,
+		},
+		{
+			title:   "missing but has default",
+			rule:    Rule{Type: TypeInt, Default: "10"},
+			present: false,
+		},
+		{
+			title:    "missing with invalid default",
+			rule:     Rule{Type: TypeInt, Default: "ten"},
+			expected: invalid,
+		},
+		{
+			title:    "empty",
+			present:  true,
+			rule:     Rule{},
+			expected: empty,
+		},
+		{
+			title:   "optional",
+			rule:    Rule{Type: TypeInt, CanBeEmpty: true},
+			present: false,
+		},
+		{
+			title:    "custom constraint",
+			value:    "x",
+			present:  true,
+			rule:     Rule{Constraints: []Constraint{func(key, value string) error { return errors.New("nope") }}},
+			expected: invalid,
+		},
+	}
+
+	for _, x := range tests {
+		t.Run(x.title, func(t *testing.T) {
+			x.rule.Key = "T_VALIDATE_RULE"
+
+			if x.present {
+				t.Setenv(x.rule.Key, x.value)
+			}
+
+			report := Validate(x.rule)
+
+			if x.expected == nil {
+				if err := report.Err(); err != nil {
+					t.Errorf("failed. expecting no failure, got %v", err)
+				}
+
+				return
+			}
+
+			if len(report.Failures) != 1 {
+				t.Fatalf("failed. expecting 1 failure, got %d", len(report.Failures))
+			}
+
+			if got := report.Failures[0].Kind; got != *x.expected {
+				t.Errorf("failed. expecting %s, got %s: %v", *x.expected, got, report.Failures[0])
+			}
+		})
+	}
+}
+
+func TestValidateReportsEveryFailure(t *testing.T) {
+	t.Setenv("T_VALIDATE_ALL_PORT", "x")
+	t.Setenv("T_VALIDATE_ALL_NAME", "api")
+	t.Setenv("T_VALIDATE_ALL_EMPTY", "")
+
+	report := Validate(
+		Rule{Key: "T_VALIDATE_ALL_PORT", Type: TypeInt},
+		Rule{Key: "T_VALIDATE_ALL_NAME"},
+		Rule{Key: "T_VALIDATE_ALL_EMPTY"},
+		Rule{Key: "T_VALIDATE_ALL_MISSING"},
+	)
+
+	expected := []string{"T_VALIDATE_ALL_PORT", "T_VALIDATE_ALL_EMPTY", "T_VALIDATE_ALL_MISSING"}
+
+	if len(report.Failures) != len(expected) {
+		t.Fatalf("failed. expecting %d failures, got %d: %v", len(expected), len(report.Failures), report)
+	}
+
+	for i, key := range expected {
+		if got := report.Failures[i].Key; got != key {
+			t.Errorf("failed. expecting failure %d on %s, got %s", i, key, got)
+		}
+	}
+}
+
+func TestValidateSetDefault(t *testing.T) {
+	const key = "T_VALIDATE_SET_DEFAULT"
+
+	t.Setenv(key, "")
+	_ = os.Unsetenv(key)
+
+	if err := Validate(Rule{Key: key, Type: TypeInt, Default: "42", SetDefault: true}).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := Get(key); got != "42" {
+		t.Errorf("failed. expecting 42, got %s", got)
+	}
+}
+
+func TestCheckAll(t *testing.T) {
+	t.Setenv("T_CHECK_ALL_PORT", "http")
+	t.Setenv("T_CHECK_ALL_HOST", "")
+	t.Setenv("T_CHECK_ALL_NAME", "api")
+
+	specs := []Spec{
+		Rule{Key: "T_CHECK_ALL_PORT", Type: TypeInt},
+		Rule{Key: "T_CHECK_ALL_HOST"},
+		Rule{Key: "T_CHECK_ALL_TOKEN"},
+		Rule{Key: "T_CHECK_ALL_NAME"},
+	}
+
+	err := CheckAll(specs...)
+	if err == nil {
+		t.Fatal("failed. expecting error")
+	}
+
+	for _, target := range []error{ErrMissing, ErrEmpty, ErrInvalid} {
+		if !errors.Is(err, target) {
+			t.Errorf("failed. expecting error to match %v", target)
+		}
+	}
+
+	var report *Report
+
+	if !errors.As(err, &report) {
+		t.Fatalf("failed. expecting *Report, got %T", err)
+	}
+
+	if len(report.Failures) != 3 {
+		t.Errorf("failed. expecting 3 failures, got %d", len(report.Failures))
+	}
+
+	for _, key := range []string{"T_CHECK_ALL_PORT", "T_CHECK_ALL_HOST", "T_CHECK_ALL_TOKEN"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("failed. expecting %s in error message %q", key, err)
+		}
+	}
+
+	if err := CheckAll(Rule{Key: "T_CHECK_ALL_NAME"}); err != nil {
+		t.Errorf("failed. expecting no error, got %v", err)
+	}
+}
+
+func TestReportTable(t *testing.T) {
+	report := &Report{Failures: []Failure{
+		{Key: "PORT", Value: "http", Kind: FailureInvalid, Err: errors.New("not a valid int")},
+		{Key: "DATABASE_URL", Kind: FailureMissing},
+	}}
+
+	expected := "KEY           PROBLEM  VALUE   DETAIL\n" +
+		"PORT          invalid  \"http\"  not a valid int\n" +
+		"DATABASE_URL  missing  \"\"      -\n"
+
+	if got := report.Table(); got != expected {
+		t.Errorf("failed. expecting\n%s\ngot\n%s", expected, got)
+	}
+}
@@ -0,0 +1,142 @@
+package envisage
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"sync"
+)
+
+// Redacted replaces sensitive values wherever envisage prints them
+const Redacted = "[REDACTED]"
+
+var (
+	sensitiveMu   sync.RWMutex
+	sensitiveKeys = map[string]struct{}{}
+)
+
+// SecretString holds a sensitive value that never prints itself.
+// fmt verbs, JSON and slog all render it as [REDACTED]; Reveal returns the actual value.
+type SecretString struct {
+	value string
+}
+
+// NewSecret wraps value in a SecretString
+func NewSecret(value string) SecretString {
+	return SecretString{value: value}
+}
+
+// Secret returns the env var value as SecretString, and marks the key as sensitive.
+// It returns the default value only if the variable is not present.
+func Secret(key string, defaultValue string) SecretString {
+	return View{}.Secret(key, defaultValue)
+}
+
+// Secret returns the env var value as SecretString, and marks the key as sensitive
+func (v View) Secret(key string, defaultValue string) SecretString {
+	v, key = v.at(key)
+
+	MarkSensitive(key)
+
+	return NewSecret(v.String(key, defaultValue))
+}
+
+// Reveal returns the actual value
+func (s SecretString) Reveal() string {
+	return s.value
+}
+
+// IsEmpty returns true if the value is empty
+func (s SecretString) IsEmpty() bool {
+	return s.value == ""
+}
+
+// String implements fmt.Stringer, returning [REDACTED]
+func (s SecretString) String() string {
+	return Redacted
+}
+
+// GoString implements fmt.GoStringer, returning [REDACTED]
+func (s SecretString) GoString() string {
+	return Redacted
+}
+
+// Format implements fmt.Formatter, so every verb, %x and %q included, prints [REDACTED]
+func (s SecretString) Format(f fmt.State, verb rune) {
+	_, _ = io.WriteString(f, Redacted)
+}
+
+// MarshalJSON implements json.Marshaler, returning "[REDACTED]"
+func (s SecretString) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + Redacted + `"`), nil
+}
+
+// LogValue implements slog.LogValuer, returning [REDACTED]
+func (s SecretString) LogValue() slog.Value {
+	return slog.StringValue(Redacted)
+}
+
+// MarkSensitive marks keys as holding sensitive values, so Masked, Dump and validation reports print [REDACTED] instead
+func MarkSensitive(keys ...string) {
+	sensitiveMu.Lock()
+	defer sensitiveMu.Unlock()
+
+	for _, k := range keys {
+		sensitiveKeys[k] = struct{}{}
+	}
+}
+
+// IsSensitive returns true if the key was marked by MarkSensitive or read with Secret
+func IsSensitive(key string) bool {
+	sensitiveMu.RLock()
+	defer sensitiveMu.RUnlock()
+
+	_, ok := sensitiveKeys[key]
+
+	return ok
+}
+
+// Masked returns the env var value as string, or [REDACTED] if the key is sensitive and the value isn't empty
+func Masked(key string) string {
+	return View{}.Masked(key)
+}
+
+// Masked returns the env var value as string, or [REDACTED] if the key is sensitive and the value isn't empty
+func (v View) Masked(key string) string {
+	v, key = v.at(key)
+
+	return mask(key, v.Get(key))
+}
+
+func mask(key, value string) string {
+	if value != "" && IsSensitive(key) {
+		return Redacted
+	}
+
+	return value
+}
+
+// Dump writes the given variables as KEY=VALUE lines sorted by key, with sensitive values masked, suitable for logs.
+// With no keys, every variable in the source set by UseSource is written. Variables not present are skipped.
+func Dump(w io.Writer, keys ...string) error {
+	if len(keys) == 0 {
+		keys = activeSource().Keys()
+	}
+
+	keys = append([]string(nil), keys...)
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v, ok := get(k)
+		if !ok {
+			continue
+		}
+
+		if _, err := fmt.Fprintf(w, "%s=%s\n", k, mask(k, v)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package envisage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSecret(t *testing.T) {
+	const key = "T_SECRET_DB_PASSWORD"
+
+	t.Setenv(key, "hunter2")
+
+	s := Secret(key, "")
+
+	if got := s.Reveal(); got != "hunter2" {
+		t.Errorf("failed. expecting hunter2, got %s", got)
+	}
+
+	if !IsSensitive(key) {
+		t.Errorf("failed. expecting %s to be marked sensitive", key)
+	}
+
+	if got := Secret("T_SECRET_NOT_PRESENT_AT_ALL", "fallback").Reveal(); got != "fallback" {
+		t.Errorf("failed. expecting default value, got %s", got)
+	}
+}
+
+func TestSecretNeverPrints(t *testing.T) {
+	s := NewSecret("hunter2")
+
+	type config struct {
+		Password SecretString `json:"password"`
+	}
+
+	j, err := json.Marshal(config{Password: s})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var logged bytes.Buffer
+
+	slog.New(slog.NewTextHandler(&logged, nil)).Info("connecting", "password", s)
+
+	outputs := map[string]string{
+		"%s":   fmt.Sprintf("%s", s),
+		"%v":   fmt.Sprintf("%v", s),
+		"%+v":  fmt.Sprintf("%+v", config{Password: s}),
+		"%#v":  fmt.Sprintf("%#v", s),
+		"%q":   fmt.Sprintf("%q", s),
+		"%x":   fmt.Sprintf("%x", s),
+		"json": string(j),
+		"slog": logged.String(),
+		"err":  fmt.Errorf("wrapped: %v", s).Error(),
+	}
+
+	for name, out := range outputs {
+		if strings.Contains(out, "hunter2") || strings.Contains(out, fmt.Sprintf("%x", "hunter2")) {
+			t.Errorf("failed. %s leaks the secret: %s", name, out)
+		}
+
+		if !strings.Contains(out, Redacted) {
+			t.Errorf("failed. expecting %s in %s output, got %s", Redacted, name, out)
+		}
+	}
+}
+
+func TestMaskedAndDump(t *testing.T) {
+	t.Setenv("T_DUMP_API_TOKEN", "abc123")
+	t.Setenv("T_DUMP_REGION", "eu-west-1")
+
+	MarkSensitive("T_DUMP_API_TOKEN")
+
+	if got := Masked("T_DUMP_API_TOKEN"); got != Redacted {
+		t.Errorf("failed. expecting %s, got %s", Redacted, got)
+	}
+
+	if got := Masked("T_DUMP_REGION"); got != "eu-west-1" {
+		t.Errorf("failed. expecting eu-west-1, got %s", got)
+	}
+
+	var sb strings.Builder
+
+	if err := Dump(&sb, "T_DUMP_REGION", "T_DUMP_API_TOKEN", "T_DUMP_NOT_PRESENT_AT_ALL"); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "T_DUMP_API_TOKEN=[REDACTED]\nT_DUMP_REGION=eu-west-1\n"; sb.String() != expected {
+		t.Errorf("failed. expecting %q, got %q", expected, sb.String())
+	}
+
+	sb.Reset()
+
+	if err := Dump(&sb); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(sb.String(), "abc123") {
+		t.Errorf("failed. full dump leaks a sensitive value")
+	}
+}
+
+func TestReportTableMasksSensitiveKeys(t *testing.T) {
+	MarkSensitive("T_TABLE_PASSWORD")
+
+	report := &Report{Failures: []Failure{
+		{Key: "T_TABLE_PASSWORD", Value: "hunter2", Kind: FailureInvalid, Err: errors.New("too short")},
+	}}
+
+	if table := report.Table(); strings.Contains(table, "hunter2") || !strings.Contains(table, Redacted) {
+		t.Errorf("failed. expecting masked value in table, got\n%s", table)
+	}
+}
+
+func TestReportTableMasksSensitiveValuesInDetails(t *testing.T) {
+	MarkSensitive("T_TABLE_MODE", "T_TABLE_PIN")
+
+	t.Setenv("T_TABLE_MODE", "hunter2")
+	t.Setenv("T_TABLE_PIN", "hunter3")
+
+	report := Validate(
+		Rule{Key: "T_TABLE_MODE", Allowed: []string{"live", "test"}},
+		Rule{Key: "T_TABLE_PIN", Type: TypeInt},
+	)
+
+	if len(report.Failures) != 2 {
+		t.Fatalf("failed. expecting 2 failures, got %v", report.Failures)
+	}
+
+	if table := report.Table(); strings.Contains(table, "hunter") {
+		t.Errorf("failed. expecting masked values in details, got\n%s", table)
+	}
+
+	if err := AllowedValues([]string{"live"}, false)("T_TABLE_MODE", "hunter2"); err == nil || strings.Contains(err.Error(), "hunter2") {
+		t.Errorf("failed. expecting masked value in constraint error, got %v", err)
+	}
+}
+
+func TestFailureErrorMasksSensitiveValues(t *testing.T) {
+	MarkSensitive("T_FAILURE_TOKEN")
+
+	t.Setenv("T_FAILURE_TOKEN", "hunter2")
+
+	leaky := func(key, value string) error {
+		return fmt.Errorf("token %q is revoked, %s", value, value)
+	}
+
+	err := CheckAll(Rule{Key: "T_FAILURE_TOKEN", Constraints: []Constraint{leaky}})
+	if err == nil {
+		t.Fatal("failed. expecting the constraint to fail")
+	}
+
+	if strings.Contains(err.Error(), "hunter2") || !strings.Contains(err.Error(), Redacted) {
+		t.Errorf("failed. expecting masked value in error, got %s", err)
+	}
+}
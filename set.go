@@ -0,0 +1,125 @@
+package envisage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Set is a deduplicated collection of list items, as returned by StringSet
+type Set struct {
+	items    map[string]struct{}
+	foldCase bool
+}
+
+// NewSet returns a Set holding the given items.
+// If foldCase is true, items are stored in lower case and Has ignores case.
+func NewSet(foldCase bool, items ...string) Set {
+	s := Set{items: make(map[string]struct{}, len(items)), foldCase: foldCase}
+
+	for _, item := range items {
+		s.items[s.normalize(item)] = struct{}{}
+	}
+
+	return s
+}
+
+func (s Set) normalize(item string) string {
+	if s.foldCase {
+		return strings.ToLower(item)
+	}
+
+	return item
+}
+
+// Has returns true if the item belongs to the set
+func (s Set) Has(item string) bool {
+	_, ok := s.items[s.normalize(item)]
+
+	return ok
+}
+
+// Len returns the number of distinct items in the set
+func (s Set) Len() int {
+	return len(s.items)
+}
+
+// Slice returns the set items sorted
+func (s Set) Slice() []string {
+	a := make([]string, 0, len(s.items))
+
+	for item := range s.items {
+		a = append(a, item)
+	}
+
+	sort.Strings(a)
+
+	return a
+}
+
+// Map returns the set items as map[string]struct{}
+func (s Set) Map() map[string]struct{} {
+	m := make(map[string]struct{}, len(s.items))
+
+	for item := range s.items {
+		m[item] = struct{}{}
+	}
+
+	return m
+}
+
+// StringSet returns the env var value as a deduplicated Set, splitting it the same way StringS does.
+// Empty items are ignored.
+// If foldCase is true, items are compared ignoring case and stored in lower case.
+// If strict is true, a duplicated item returns the default value and an error instead of being silently merged.
+// It returns the default value only if the variable is not present or, in strict mode, has duplicates.
+func StringSet(key, separator string, foldCase, strict bool, defaultValue []string) (Set, error) {
+	return View{}.StringSet(key, separator, foldCase, strict, defaultValue)
+}
+
+// StringSet returns the env var value as a Set
+func (v View) StringSet(key, separator string, foldCase, strict bool, defaultValue []string) (Set, error) {
+	v, key = v.at(key)
+
+	items := v.StringS(key, separator, nil)
+	if items == nil {
+		return NewSet(foldCase, defaultValue...), nil
+	}
+
+	s := NewSet(foldCase)
+
+	for _, item := range items {
+		if item == "" {
+			continue
+		}
+
+		n := s.normalize(item)
+
+		if _, dup := s.items[n]; dup && strict {
+			return NewSet(foldCase, defaultValue...), fmt.Errorf("environment variable %s has duplicate item %q", key, item)
+		}
+
+		s.items[n] = struct{}{}
+	}
+
+	return s, nil
+}
+
+// SetStringSet sets the value of the environment variable named by the key, as the sorted items joined by separator,
+// the format read by StringSet. An empty item is dropped, as StringSet ignores it.
+func SetStringSet(key, separator string, value Set) error {
+	return View{}.SetStringSet(key, separator, value)
+}
+
+// SetStringSet sets the value of the environment variable named by the key, in the format read by StringSet
+func (v View) SetStringSet(key, separator string, value Set) error {
+	v, key = v.at(key)
+
+	items := value.Slice()
+
+	if len(items) > 0 && items[0] == "" {
+		items = items[1:]
+	}
+
+	return v.SetStringS(key, separator, items)
+}
@@ -0,0 +1,147 @@
+package envisage
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+)
+
+func TestStringSet(t *testing.T) {
+	type testCase struct {
+		title,
+		key,
+		value string
+		foldCase,
+		strict bool
+		expected  []string
+		has       []string
+		hasNot    []string
+		expectErr bool
+	}
+
+	tests := []testCase{
+		{
+			title:    "duplicates are merged",
+			key:      "T_SET_DUPLICATES",
+			value:    "a,b,a",
+			expected: []string{"a", "b"},
+			has:      []string{"a", "b"},
+			hasNot:   []string{"A", "c"},
+		},
+		{
+			title:    "case folding",
+			key:      "T_SET_FOLD_CASE",
+			value:    "Alpha,ALPHA,beta",
+			foldCase: true,
+			expected: []string{"alpha", "beta"},
+			has:      []string{"alpha", "ALPHA", "Beta"},
+		},
+		{
+			title:    "empty items are ignored",
+			key:      "T_SET_EMPTY_ITEMS",
+			value:    "a,,b,",
+			expected: []string{"a", "b"},
+		},
+		{
+			title:    "empty value",
+			key:      "T_SET_EMPTY",
+			value:    "",
+			expected: []string{},
+		},
+		{
+			title:    "strict without duplicates",
+			key:      "T_SET_STRICT_OK",
+			value:    "a,b",
+			strict:   true,
+			expected: []string{"a", "b"},
+		},
+		{
+			title:     "strict with duplicates",
+			key:       "T_SET_STRICT_DUPLICATE",
+			value:     "a,b,a",
+			strict:    true,
+			expectErr: true,
+		},
+		{
+			title:     "strict with duplicates only when folding case",
+			key:       "T_SET_STRICT_FOLD_DUPLICATE",
+			value:     "a,A",
+			foldCase:  true,
+			strict:    true,
+			expectErr: true,
+		},
+	}
+
+	for _, x := range tests {
+		t.Run(x.title, func(t *testing.T) {
+			t.Setenv(x.key, x.value)
+
+			got, err := StringSet(x.key, ",", x.foldCase, x.strict, nil)
+			if x.expectErr {
+				if err == nil {
+					t.Errorf("failed. expecting error, got %#v", got.Slice())
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(x.expected, got.Slice()) {
+				t.Errorf("failed. expecting %#v, got %#v", x.expected, got.Slice())
+			}
+
+			for _, item := range x.has {
+				if !got.Has(item) {
+					t.Errorf("failed. expecting set to have %s", item)
+				}
+			}
+
+			for _, item := range x.hasNot {
+				if got.Has(item) {
+					t.Errorf("failed. expecting set not to have %s", item)
+				}
+			}
+		})
+	}
+}
+
+func TestStringSetDefaultValue(t *testing.T) {
+	got, err := StringSet("T_SET_NOT_PRESENT_AT_ALL", ",", false, true, []string{"x", "y", "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Len() != 2 || !got.Has("x") || !got.Has("y") {
+		t.Errorf("failed. expecting default value, got %#v", got.Slice())
+	}
+}
+
+func TestSetStringSetRoundTrip(t *testing.T) {
+	t.Setenv("T_ROUNDTRIP_SET", "")
+
+	property := func(items []string, foldCase bool) bool {
+		value := NewSet(foldCase, items...)
+
+		if err := SetStringSet("T_ROUNDTRIP_SET", "|", value); err != nil {
+			return strings.Contains(strings.Join(items, ""), "|") || strings.ContainsRune(strings.Join(items, ""), 0)
+		}
+
+		got, err := StringSet("T_ROUNDTRIP_SET", "|", foldCase, false, nil)
+
+		// empty items are dropped on both ends
+		expected := value.Slice()
+		if len(expected) > 0 && expected[0] == "" {
+			expected = expected[1:]
+		}
+
+		return err == nil && got.Len() == len(expected) && (len(expected) == 0 || reflect.DeepEqual(expected, got.Slice()))
+	}
+
+	if err := quick.Check(property, nil); err != nil {
+		t.Errorf("failed. StringSet doesn't round trip: %v", err)
+	}
+}
@@ -0,0 +1,288 @@
+package envisage
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Signature algorithms, as named in signing keys and signatures
+const (
+	AlgorithmHMAC    = "hmac-sha256"
+	AlgorithmEd25519 = "ed25519"
+)
+
+const (
+	signatureHeader    = "# envisage:signature v1 "
+	ed25519PublicLabel = "ed25519-public"
+	hmacKeySize        = 32
+)
+
+var (
+	// ErrUnsigned is returned when a signature is required but none is found
+	ErrUnsigned = errors.New("file is not signed")
+	// ErrBadSignature is returned when a signature doesn't match the contents, meaning they were modified after signing
+	ErrBadSignature = errors.New("signature doesn't match, file was modified after signing")
+)
+
+// Verifier checks the signature of .env files
+type Verifier interface {
+	// Algorithm returns the signature algorithm, AlgorithmHMAC or AlgorithmEd25519
+	Algorithm() string
+	// Verify returns true if signature is valid for data
+	Verify(data, signature []byte) bool
+}
+
+// Signer signs .env files
+type Signer interface {
+	Verifier
+	// Sign returns the signature of data
+	Sign(data []byte) ([]byte, error)
+}
+
+// HMACKey is a shared secret, signing and verifying with HMAC-SHA256
+type HMACKey []byte
+
+// Algorithm implements Verifier
+func (k HMACKey) Algorithm() string {
+	return AlgorithmHMAC
+}
+
+// Sign implements Signer
+func (k HMACKey) Sign(data []byte) ([]byte, error) {
+	mac := hmac.New(sha256.New, k)
+	mac.Write(data)
+
+	return mac.Sum(nil), nil
+}
+
+// Verify implements Verifier
+func (k HMACKey) Verify(data, signature []byte) bool {
+	expected, _ := k.Sign(data)
+
+	return hmac.Equal(expected, signature)
+}
+
+// Ed25519PublicKey verifies signatures made with the matching Ed25519PrivateKey, and can be shared with every host loading files
+type Ed25519PublicKey ed25519.PublicKey
+
+// Algorithm implements Verifier
+func (k Ed25519PublicKey) Algorithm() string {
+	return AlgorithmEd25519
+}
+
+// Verify implements Verifier
+func (k Ed25519PublicKey) Verify(data, signature []byte) bool {
+	return len(k) == ed25519.PublicKeySize && ed25519.Verify(ed25519.PublicKey(k), data, signature)
+}
+
+// Ed25519PrivateKey signs files, and is only needed where they are reviewed
+type Ed25519PrivateKey ed25519.PrivateKey
+
+// Algorithm implements Verifier
+func (k Ed25519PrivateKey) Algorithm() string {
+	return AlgorithmEd25519
+}
+
+// Sign implements Signer
+func (k Ed25519PrivateKey) Sign(data []byte) ([]byte, error) {
+	if len(k) != ed25519.PrivateKeySize {
+		return nil, errors.New("invalid ed25519 private key")
+	}
+
+	return ed25519.Sign(ed25519.PrivateKey(k), data), nil
+}
+
+// Verify implements Verifier
+func (k Ed25519PrivateKey) Verify(data, signature []byte) bool {
+	return k.Public().Verify(data, signature)
+}
+
+// Public returns the public key, to verify signatures
+func (k Ed25519PrivateKey) Public() Ed25519PublicKey {
+	if len(k) != ed25519.PrivateKeySize {
+		return nil
+	}
+
+	return Ed25519PublicKey(ed25519.PrivateKey(k).Public().(ed25519.PublicKey))
+}
+
+// GenerateSigningKey returns a new random signing key for the given algorithm, formatted as <algorithm>:<base64>,
+// like ed25519:bWFueSBieXRlcw==. Keep it secret; for ed25519, hand out the key returned by PublicKey instead.
+func GenerateSigningKey(algorithm string) (string, error) {
+	var key []byte
+
+	switch algorithm {
+	case AlgorithmHMAC:
+		key = make([]byte, hmacKeySize)
+
+		if _, err := rand.Read(key); err != nil {
+			return "", err
+		}
+	case AlgorithmEd25519:
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return "", err
+		}
+
+		key = private.Seed()
+	default:
+		return "", fmt.Errorf("unknown signature algorithm %q", algorithm)
+	}
+
+	return algorithm + ":" + base64.StdEncoding.EncodeToString(key), nil
+}
+
+// PublicKey returns the ed25519 public key matching a signing key returned by GenerateSigningKey, formatted as ed25519-public:<base64>
+func PublicKey(signingKey string) (string, error) {
+	signer, err := ParseSigner(signingKey)
+	if err != nil {
+		return "", err
+	}
+
+	private, ok := signer.(Ed25519PrivateKey)
+	if !ok {
+		return "", fmt.Errorf("%s keys have no public key, the key itself verifies signatures", signer.Algorithm())
+	}
+
+	return ed25519PublicLabel + ":" + base64.StdEncoding.EncodeToString(private.Public()), nil
+}
+
+// ParseSigner returns the signer for a key returned by GenerateSigningKey
+func ParseSigner(key string) (Signer, error) {
+	label, b, err := parseSigningKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	switch label {
+	case AlgorithmHMAC:
+		if len(b) < hmacKeySize {
+			return nil, fmt.Errorf("hmac key has %d bytes, expecting at least %d", len(b), hmacKeySize)
+		}
+
+		return HMACKey(b), nil
+	case AlgorithmEd25519:
+		switch len(b) {
+		case ed25519.SeedSize:
+			return Ed25519PrivateKey(ed25519.NewKeyFromSeed(b)), nil
+		case ed25519.PrivateKeySize:
+			return Ed25519PrivateKey(b), nil
+		}
+
+		return nil, fmt.Errorf("ed25519 private key has %d bytes, expecting %d", len(b), ed25519.SeedSize)
+	case ed25519PublicLabel:
+		return nil, errors.New("public keys can't sign, use the private key")
+	}
+
+	return nil, fmt.Errorf("unknown signature algorithm %q", label)
+}
+
+// ParseVerifier returns the verifier for a key returned by GenerateSigningKey or PublicKey
+func ParseVerifier(key string) (Verifier, error) {
+	label, b, err := parseSigningKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if label != ed25519PublicLabel {
+		return ParseSigner(key)
+	}
+
+	if len(b) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("ed25519 public key has %d bytes, expecting %d", len(b), ed25519.PublicKeySize)
+	}
+
+	return Ed25519PublicKey(b), nil
+}
+
+func parseSigningKey(key string) (string, []byte, error) {
+	label, encoded, found := strings.Cut(strings.TrimSpace(key), ":")
+	if !found {
+		return "", nil, errors.New("signing key must be formatted as <algorithm>:<base64>")
+	}
+
+	b, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s key is not valid base64", label)
+	}
+
+	return label, b, nil
+}
+
+// Sign returns the signature of data as a single line, to be saved as a detached signature file, like .env.sig
+func Sign(data []byte, signer Signer) (string, error) {
+	signature, err := signer.Sign(data)
+	if err != nil {
+		return "", err
+	}
+
+	return signatureHeader + signer.Algorithm() + " " + base64.StdEncoding.EncodeToString(signature) + "\n", nil
+}
+
+// AppendSignature returns data with its signature added as a trailing comment line, replacing any previous one.
+// The comment is ignored by LoadFromFile, so signed files stay readable by older versions.
+func AppendSignature(data []byte, signer Signer) ([]byte, error) {
+	body, _ := splitSignature(data)
+
+	if len(body) > 0 && !bytes.HasSuffix(body, []byte("\n")) {
+		body = append(body[:len(body):len(body)], '\n')
+	}
+
+	signature, err := Sign(body, signer)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(body[:len(body):len(body)], signature...), nil
+}
+
+// VerifySignature checks data against a detached signature, as returned by Sign, or against its trailing signature when detached is nil.
+// It returns ErrUnsigned if there is no signature, and ErrBadSignature if it doesn't match.
+func VerifySignature(data, detached []byte, verifier Verifier) error {
+	line := detached
+
+	if line == nil {
+		data, line = splitSignature(data)
+
+		if line == nil {
+			return ErrUnsigned
+		}
+	}
+
+	rest, found := bytes.CutPrefix(bytes.TrimSpace(line), []byte(signatureHeader))
+	fields := strings.Fields(string(rest))
+
+	if !found || len(fields) != 2 {
+		return fmt.Errorf("%w: malformed signature", ErrBadSignature)
+	}
+
+	if fields[0] != verifier.Algorithm() {
+		return fmt.Errorf("%w: signed with %s, expecting %s", ErrBadSignature, fields[0], verifier.Algorithm())
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil || !verifier.Verify(data, signature) {
+		return ErrBadSignature
+	}
+
+	return nil
+}
+
+// splitSignature returns the signed contents and the trailing signature line, nil if there is none
+func splitSignature(data []byte) ([]byte, []byte) {
+	trimmed := bytes.TrimRight(data, "\r\n")
+	start := bytes.LastIndexByte(trimmed, '\n') + 1
+
+	if !bytes.HasPrefix(trimmed[start:], []byte(signatureHeader)) {
+		return data, nil
+	}
+
+	return data[:start], trimmed[start:]
+}
@@ -0,0 +1,223 @@
+package envisage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func mustSigner(t *testing.T, algorithm string) (Signer, Verifier) {
+	t.Helper()
+
+	key, err := GenerateSigningKey(algorithm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := ParseSigner(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifierKey := key
+
+	if algorithm == AlgorithmEd25519 {
+		if verifierKey, err = PublicKey(key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	verifier, err := ParseVerifier(verifierKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signer, verifier
+}
+
+func TestSignature(t *testing.T) {
+	data := []byte("T_SIGN_HOST=db\nT_SIGN_PORT=5432")
+
+	for _, algorithm := range []string{AlgorithmHMAC, AlgorithmEd25519} {
+		t.Run(algorithm, func(t *testing.T) {
+			signer, verifier := mustSigner(t, algorithm)
+
+			signed, err := AppendSignature(data, signer)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := VerifySignature(signed, nil, verifier); err != nil {
+				t.Errorf("failed. expecting valid trailing signature, got %v", err)
+			}
+
+			resigned, err := AppendSignature(signed, signer)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if strings.Count(string(resigned), "envisage:signature") != 1 {
+				t.Errorf("failed. expecting signature to be replaced, got %q", resigned)
+			}
+
+			tampered := []byte(strings.Replace(string(signed), "5432", "5433", 1))
+
+			if err := VerifySignature(tampered, nil, verifier); !errors.Is(err, ErrBadSignature) {
+				t.Errorf("failed. expecting ErrBadSignature, got %v", err)
+			}
+
+			detached, err := Sign(data, signer)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := VerifySignature(data, []byte(detached), verifier); err != nil {
+				t.Errorf("failed. expecting valid detached signature, got %v", err)
+			}
+
+			if err := VerifySignature(data, nil, verifier); !errors.Is(err, ErrUnsigned) {
+				t.Errorf("failed. expecting ErrUnsigned, got %v", err)
+			}
+		})
+	}
+
+	_, hmacVerifier := mustSigner(t, AlgorithmHMAC)
+	ed25519Signer, _ := mustSigner(t, AlgorithmEd25519)
+
+	signed, err := AppendSignature(data, ed25519Signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifySignature(signed, nil, hmacVerifier); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("failed. expecting algorithm mismatch to fail, got %v", err)
+	}
+}
+
+func TestParseSigningKey(t *testing.T) {
+	type testCase struct {
+		title,
+		key string
+	}
+
+	tests := []testCase{
+		{title: "no algorithm", key: "c2VjcmV0"},
+		{title: "unknown algorithm", key: "rsa:c2VjcmV0"},
+		{title: "invalid base64", key: "ed25519:***"},
+		{title: "short hmac key", key: "hmac-sha256:c2VjcmV0"},
+		{title: "public key can't sign", key: "ed25519-public:" + strings.Repeat("A", 43) + "="},
+	}
+
+	for _, x := range tests {
+		t.Run(x.title, func(t *testing.T) {
+			if _, err := ParseSigner(x.key); err == nil {
+				t.Error("failed. expecting error")
+			}
+		})
+	}
+
+	hmacKey, err := GenerateSigningKey(AlgorithmHMAC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := PublicKey(hmacKey); err == nil {
+		t.Error("failed. expecting hmac keys to have no public key")
+	}
+}
+
+func TestLoadVerifiesSignature(t *testing.T) {
+	signer, verifier := mustSigner(t, AlgorithmEd25519)
+
+	dir := t.TempDir()
+	trailing := filepath.Join(dir, "trailing.env")
+	detached := filepath.Join(dir, "detached.env")
+	unsigned := filepath.Join(dir, "unsigned.env")
+	data := []byte("T_SIGN_LOAD=reviewed\n")
+
+	signed, err := AppendSignature(data, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signature, err := Sign(data, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for path, content := range map[string][]byte{trailing: signed, detached: data, detached + ".sig": []byte(signature), unsigned: data} {
+		if err := os.WriteFile(path, content, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, path := range []string{trailing, detached} {
+		result, err := Load(path, LoadOptions{ErrorIfFileDoesntExist: true, Verifier: verifier})
+		if err != nil {
+			t.Fatalf("failed. expecting %s to load, got %v", path, err)
+		}
+
+		if !result.Verified || result.Values["T_SIGN_LOAD"] != "reviewed" {
+			t.Errorf("failed. expecting verified value, got %+v", result)
+		}
+	}
+
+	if _, err := Load(unsigned, LoadOptions{Verifier: verifier}); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("failed. expecting ErrUnsigned, got %v", err)
+	}
+
+	if err := os.WriteFile(detached, []byte("T_SIGN_LOAD=edited\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(detached, LoadOptions{UpdateEnvironment: true, Verifier: verifier}); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("failed. expecting ErrBadSignature, got %v", err)
+	}
+
+	if IsThere("T_SIGN_LOAD") {
+		t.Error("failed. expecting tampered file not to update environment")
+	}
+
+	m, err := LoadFromFile(trailing, false, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(m) != 1 {
+		t.Errorf("failed. expecting signature comment to be ignored, got %v", m)
+	}
+}
+
+func TestSignedEncryptedFile(t *testing.T) {
+	keyring, keys := testKeyring(t, 1)
+	t.Setenv(KeyEnv, keys[0])
+
+	encrypted, err := Encrypt([]byte("T_SIGN_ENC=hunter2\n"), keyring)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, verifier := mustSigner(t, AlgorithmHMAC)
+
+	signed, err := AppendSignature(encrypted, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "prod.env")
+
+	if err := os.WriteFile(path, signed, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Load(path, LoadOptions{Verifier: verifier})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := result.Values["T_SIGN_ENC"]; got != "hunter2" {
+		t.Errorf("failed. expecting hunter2, got %s", got)
+	}
+}
@@ -0,0 +1,143 @@
+package envisage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Unset is a value that makes With and Override remove the variable instead of setting it.
+// It's a NUL byte, which no environment variable value can hold.
+const Unset = "\x00"
+
+// Cleaner is the part of testing.TB used by Override and RestoreOnCleanup, so *testing.T, *testing.B and *testing.F satisfy it
+type Cleaner interface {
+	Helper()
+	Cleanup(func())
+	Fatalf(format string, args ...any)
+}
+
+// State is a copy of the whole process environment, as taken by Snapshot
+type State struct {
+	vars map[string]string
+}
+
+// Snapshot returns a copy of the whole process environment, to be brought back later with Restore
+func Snapshot() *State {
+	s := &State{vars: make(map[string]string)}
+
+	for _, kv := range os.Environ() {
+		if k, v, found := strings.Cut(kv, "="); found && k != "" {
+			s.vars[k] = v
+		}
+	}
+
+	return s
+}
+
+// Restore brings the process environment back to the snapshot: variables set since are unset,
+// and variables changed or unset since get their previous value back
+func (s *State) Restore() error {
+	var errs []error
+
+	for _, k := range Environment().Keys() {
+		if _, ok := s.vars[k]; !ok {
+			if err := os.Unsetenv(k); err != nil {
+				errs = append(errs, fmt.Errorf("environment variable %s can't be unset: %w", k, err))
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(s.vars))
+	for k := range s.vars {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if v, ok := os.LookupEnv(k); ok && v == s.vars[k] {
+			continue
+		}
+
+		if err := os.Setenv(k, s.vars[k]); err != nil {
+			errs = append(errs, fmt.Errorf("environment variable %s can't be restored: %w", k, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// With sets vars, unsetting those valued Unset, runs fn, and restores the whole process environment afterwards,
+// even if fn panics. Variables fn sets are removed too. If vars can't be applied, fn isn't run.
+// The process environment is shared by every goroutine, so don't use With in parallel tests.
+func With(vars map[string]string, fn func()) (err error) {
+	state := Snapshot()
+
+	defer func() {
+		if rerr := state.Restore(); rerr != nil {
+			err = errors.Join(err, rerr)
+		}
+	}()
+
+	if err := override(vars); err != nil {
+		return err
+	}
+
+	fn()
+
+	return nil
+}
+
+// Override sets vars, unsetting those valued Unset, and restores the whole process environment when the test ends.
+// It fails the test if vars can't be applied.
+func Override(t Cleaner, vars map[string]string) {
+	t.Helper()
+
+	RestoreOnCleanup(t)
+
+	if err := override(vars); err != nil {
+		t.Fatalf("envisage: %v", err)
+	}
+}
+
+// RestoreOnCleanup takes a snapshot of the process environment, and restores it when the test ends
+func RestoreOnCleanup(t Cleaner) {
+	t.Helper()
+
+	state := Snapshot()
+
+	t.Cleanup(func() {
+		if err := state.Restore(); err != nil {
+			t.Fatalf("envisage: %v", err)
+		}
+	})
+}
+
+func override(vars map[string]string) error {
+	values := make(map[string]string, len(vars))
+
+	for k, v := range vars {
+		if v != Unset {
+			values[k] = v
+		}
+	}
+
+	if err := From(Environment()).SetMany(values); err != nil {
+		return err
+	}
+
+	for k, v := range vars {
+		if v != Unset {
+			continue
+		}
+
+		if err := os.Unsetenv(k); err != nil {
+			return fmt.Errorf("environment variable %s can't be unset: %w", k, err)
+		}
+	}
+
+	return nil
+}
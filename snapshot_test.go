@@ -0,0 +1,111 @@
+package envisage
+
+import (
+	"os"
+	"testing"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	RestoreOnCleanup(t)
+
+	if err := SetMany(map[string]string{"T_SNAPSHOT_CHANGED": "before", "T_SNAPSHOT_REMOVED": "before"}); err != nil {
+		t.Fatal(err)
+	}
+
+	state := Snapshot()
+
+	_ = SetString("T_SNAPSHOT_CHANGED", "after")
+	_ = SetString("T_SNAPSHOT_ADDED", "after")
+	_ = os.Unsetenv("T_SNAPSHOT_REMOVED")
+
+	if err := state.Restore(); err != nil {
+		t.Fatal(err)
+	}
+
+	for k, expected := range map[string]string{"T_SNAPSHOT_CHANGED": "before", "T_SNAPSHOT_REMOVED": "before"} {
+		if got := Get(k); got != expected {
+			t.Errorf("failed. expecting %s to be %s, got %s", k, expected, got)
+		}
+	}
+
+	if IsThere("T_SNAPSHOT_ADDED") {
+		t.Error("failed. expecting T_SNAPSHOT_ADDED to be removed")
+	}
+}
+
+func TestWith(t *testing.T) {
+	t.Setenv("T_WITH_PRESENT", "outside")
+	t.Setenv("T_WITH_REMOVED", "outside")
+
+	var inside map[string]string
+
+	err := With(map[string]string{"T_WITH_PRESENT": "inside", "T_WITH_MISSING": "inside", "T_WITH_REMOVED": Unset}, func() {
+		_ = SetString("T_WITH_LEAKED", "x")
+
+		inside = map[string]string{
+			"T_WITH_PRESENT": Get("T_WITH_PRESENT"),
+			"T_WITH_MISSING": Get("T_WITH_MISSING"),
+		}
+
+		if IsThere("T_WITH_REMOVED") {
+			t.Error("failed. expecting T_WITH_REMOVED to be unset inside With")
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if inside["T_WITH_PRESENT"] != "inside" || inside["T_WITH_MISSING"] != "inside" {
+		t.Errorf("failed. expecting values to be set inside With, got %v", inside)
+	}
+
+	if got := Get("T_WITH_PRESENT"); got != "outside" {
+		t.Errorf("failed. expecting T_WITH_PRESENT restored, got %s", got)
+	}
+
+	if got := Get("T_WITH_REMOVED"); got != "outside" {
+		t.Errorf("failed. expecting T_WITH_REMOVED restored, got %s", got)
+	}
+
+	for _, k := range []string{"T_WITH_MISSING", "T_WITH_LEAKED"} {
+		if IsThere(k) {
+			t.Errorf("failed. expecting %s to be removed", k)
+		}
+	}
+
+	ran := false
+
+	if err := With(map[string]string{"T_WITH=INVALID": "x"}, func() { ran = true }); err == nil || ran {
+		t.Errorf("failed. expecting error and fn not to run, got %v", err)
+	}
+}
+
+func TestWithPanic(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("failed. expecting the panic to propagate")
+		}
+
+		if IsThere("T_WITH_PANIC") {
+			t.Error("failed. expecting environment restored after panic")
+		}
+	}()
+
+	_ = With(map[string]string{"T_WITH_PANIC": "x"}, func() {
+		panic("boom")
+	})
+}
+
+func TestOverride(t *testing.T) {
+	t.Run("inner", func(t *testing.T) {
+		Override(t, map[string]string{"T_OVERRIDE": "x"})
+
+		if got := Get("T_OVERRIDE"); got != "x" {
+			t.Errorf("failed. expecting x, got %s", got)
+		}
+	})
+
+	if IsThere("T_OVERRIDE") {
+		t.Error("failed. expecting T_OVERRIDE removed when the subtest ends")
+	}
+}
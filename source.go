@@ -0,0 +1,116 @@
+package envisage
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+// Source provides the values read by the getters. The process environment is the default source.
+type Source interface {
+	// Lookup returns the value of the variable named by the key, and whether it is present
+	Lookup(key string) (string, bool, error)
+	// Keys returns the name of every variable present
+	Keys() []string
+}
+
+// WritableSource is a Source the setters can write to, when given to UseSource or From
+type WritableSource interface {
+	Source
+	// Set sets the value of the variable named by the key
+	Set(key, value string) error
+	// Unset removes the variable named by the key
+	Unset(key string) error
+}
+
+type environment struct{}
+
+func (environment) Lookup(key string) (string, bool, error) {
+	s, ok := os.LookupEnv(key)
+
+	return s, ok, nil
+}
+
+func (environment) Set(key, value string) error {
+	return os.Setenv(key, value)
+}
+
+func (environment) Unset(key string) error {
+	return os.Unsetenv(key)
+}
+
+func (environment) Keys() []string {
+	var keys []string
+
+	for _, kv := range os.Environ() {
+		if k, _, found := strings.Cut(kv, "="); found && k != "" {
+			keys = append(keys, k)
+		}
+	}
+
+	return keys
+}
+
+// Environment returns the process environment as a Source
+func Environment() Source {
+	return environment{}
+}
+
+type layered []Source
+
+func (l layered) Lookup(key string) (string, bool, error) {
+	for _, s := range l {
+		v, ok, err := s.Lookup(key)
+		if err != nil || ok {
+			return v, ok, err
+		}
+	}
+
+	return "", false, nil
+}
+
+func (l layered) Keys() []string {
+	seen := make(map[string]struct{})
+
+	var keys []string
+
+	for _, s := range l {
+		for _, k := range s.Keys() {
+			if _, dup := seen[k]; !dup {
+				seen[k] = struct{}{}
+				keys = append(keys, k)
+			}
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Layered returns a Source that looks a key up in each source in turn, and returns the first value found.
+// Layered(Environment(), dir) lets the process environment override the files in dir.
+func Layered(sources ...Source) Source {
+	return layered(sources)
+}
+
+// UseSource makes every getter read from the given source instead of the process environment.
+// A nil source restores the process environment. Setters write to the source if it's a WritableSource,
+// or else to the process environment, so layer Environment() on top of other sources to read back what was set.
+func UseSource(source Source) {
+	lookupMu.Lock()
+	defer lookupMu.Unlock()
+
+	currentSource = source
+}
+
+func activeSource() Source {
+	lookupMu.RLock()
+	defer lookupMu.RUnlock()
+
+	if currentSource == nil {
+		return environment{}
+	}
+
+	return currentSource
+}
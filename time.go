@@ -0,0 +1,74 @@
+package envisage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Duration returns the env var value as time.Duration, like 1m30s
+// It returns the default value only if either the variable is not present or if its value cannot be correctly converted for time.Duration
+func Duration(key string, defaultValue time.Duration) time.Duration {
+	return View{}.Duration(key, defaultValue)
+}
+
+// Duration returns the env var value as time.Duration
+func (v View) Duration(key string, defaultValue time.Duration) time.Duration {
+	v, key = v.at(key)
+
+	if s, ok := v.get(key); ok {
+		if d, err := time.ParseDuration(strings.TrimSpace(s)); err == nil {
+			return d
+		}
+	}
+
+	return defaultValue
+}
+
+// Time returns the env var value as time.Time, parsed with layout, like time.RFC3339
+// It returns the default value only if the variable is not present. An invalid value returns the default value and an error.
+func Time(key, layout string, defaultValue time.Time) (time.Time, error) {
+	return View{}.Time(key, layout, defaultValue)
+}
+
+// Time returns the env var value as time.Time, parsed with layout
+func (v View) Time(key, layout string, defaultValue time.Time) (time.Time, error) {
+	v, key = v.at(key)
+
+	s, ok, err := v.lookup(key)
+	if err != nil || !ok {
+		return defaultValue, err
+	}
+
+	t, err := time.Parse(layout, strings.TrimSpace(s))
+	if err != nil {
+		return defaultValue, fmt.Errorf("environment variable %s is not a valid time: %w", key, err)
+	}
+
+	return t, nil
+}
+
+// SetDuration sets the value of the environment variable named by the key, formatted as time.Duration.String does.
+func SetDuration(key string, value time.Duration) error {
+	return View{}.SetDuration(key, value)
+}
+
+// SetDuration sets the value of the environment variable named by the key, in the format read by Duration
+func (v View) SetDuration(key string, value time.Duration) error {
+	v, key = v.at(key)
+
+	return v.set(key, value.String())
+}
+
+// SetTime sets the value of the environment variable named by the key, formatted with layout, the format read by Time.
+// Use time.RFC3339Nano to read back the exact instant.
+func SetTime(key, layout string, value time.Time) error {
+	return View{}.SetTime(key, layout, value)
+}
+
+// SetTime sets the value of the environment variable named by the key, in the format read by Time
+func (v View) SetTime(key, layout string, value time.Time) error {
+	v, key = v.at(key)
+
+	return v.set(key, value.Format(layout))
+}
@@ -0,0 +1,86 @@
+package envisage
+
+import (
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+func TestDuration(t *testing.T) {
+	type testCase struct {
+		title,
+		key,
+		value string
+		expected time.Duration
+	}
+
+	tests := []testCase{
+		{title: "valid duration", key: "T_DURATION_VALID", value: "1m30s", expected: 90 * time.Second},
+		{title: "surrounding spaces", key: "T_DURATION_SPACES", value: " 250ms ", expected: 250 * time.Millisecond},
+		{title: "invalid duration", key: "T_DURATION_INVALID", value: "90", expected: time.Minute},
+	}
+
+	for _, x := range tests {
+		t.Run(x.title, func(t *testing.T) {
+			t.Setenv(x.key, x.value)
+
+			if got := Duration(x.key, time.Minute); got != x.expected {
+				t.Errorf("failed. expecting %s, got %s", x.expected, got)
+			}
+		})
+	}
+
+	if got := Duration("T_DURATION_MISSING", time.Minute); got != time.Minute {
+		t.Errorf("failed. expecting default value, got %s", got)
+	}
+}
+
+func TestTime(t *testing.T) {
+	t.Setenv("T_TIME_VALID", "2024-03-01T10:00:00Z")
+	t.Setenv("T_TIME_INVALID", "yesterday")
+
+	got, err := Time("T_TIME_VALID", time.RFC3339, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC); !got.Equal(expected) {
+		t.Errorf("failed. expecting %s, got %s", expected, got)
+	}
+
+	if _, err := Time("T_TIME_INVALID", time.RFC3339, time.Time{}); err == nil {
+		t.Error("failed. expecting error for invalid time")
+	}
+}
+
+func TestSetDurationAndTimeRoundTrip(t *testing.T) {
+	t.Setenv("T_ROUNDTRIP_DURATION", "")
+	t.Setenv("T_ROUNDTRIP_TIME", "")
+
+	duration := func(value int64) bool {
+		if err := SetDuration("T_ROUNDTRIP_DURATION", time.Duration(value)); err != nil {
+			return false
+		}
+
+		return Duration("T_ROUNDTRIP_DURATION", -1) == time.Duration(value)
+	}
+
+	instant := func(sec int64, nsec uint32, offset int16) bool {
+		zone := time.FixedZone("", int(offset)%(14*60+1)*60)
+		value := time.Unix(sec%(1<<35), int64(nsec%1e9)).In(zone)
+
+		if err := SetTime("T_ROUNDTRIP_TIME", time.RFC3339Nano, value); err != nil {
+			return false
+		}
+
+		got, err := Time("T_ROUNDTRIP_TIME", time.RFC3339Nano, time.Time{})
+
+		return err == nil && got.Equal(value)
+	}
+
+	for name, property := range map[string]any{"Duration": duration, "Time": instant} {
+		if err := quick.Check(property, nil); err != nil {
+			t.Errorf("failed. %s doesn't round trip: %v", name, err)
+		}
+	}
+}
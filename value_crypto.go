@@ -0,0 +1,96 @@
+package envisage
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+const (
+	encryptedValuePrefix  = "ENC["
+	encryptedValueSuffix  = "]"
+	encryptedValueVersion = "v1"
+)
+
+// KeyProvider returns decryption keys by id, as recorded in encrypted values. Keyring is a KeyProvider.
+type KeyProvider interface {
+	Key(id string) ([]byte, error)
+}
+
+var (
+	keyProviderMu sync.RWMutex
+	keyProvider   KeyProvider
+)
+
+// SetKeyProvider registers the provider used to decrypt ENC[...] values, turning their decryption on in the getters and LoadFromFile.
+// With no provider registered, or after SetKeyProvider(nil), values are read as they are, so use the keyring returned by
+// LoadKeyring to decrypt with the keys from the environment.
+func SetKeyProvider(provider KeyProvider) {
+	keyProviderMu.Lock()
+	defer keyProviderMu.Unlock()
+
+	keyProvider = provider
+}
+
+func activeKeyProvider() KeyProvider {
+	keyProviderMu.RLock()
+	defer keyProviderMu.RUnlock()
+
+	return keyProvider
+}
+
+// IsEncryptedValue returns true if value is wrapped as ENC[...], as produced by EncryptValue
+func IsEncryptedValue(value string) bool {
+	return strings.HasPrefix(value, encryptedValuePrefix) && strings.HasSuffix(value, encryptedValueSuffix)
+}
+
+// EncryptValue returns the value of the variable named by the key encrypted with the keyring primary key using AES-256-GCM,
+// wrapped as ENC[v1:<key id>:<base64>], ready to be pasted in a .env file like DB_PASSWORD=ENC[v1:...].
+// The key is authenticated along with the value, so it only decrypts as that variable. Getters and LoadFromFile decrypt it transparently.
+func EncryptValue(key, value string, keyring *Keyring) (string, error) {
+	header := encryptedValueVersion + ":" + keyring.PrimaryID()
+
+	sealed, err := seal(keyring.keys[0].secret, []byte(value), additionalData(header, key))
+	if err != nil {
+		return "", err
+	}
+
+	return encryptedValuePrefix + header + ":" + base64.StdEncoding.EncodeToString(sealed) + encryptedValueSuffix, nil
+}
+
+// DecryptValue returns the plain value of an ENC[...] value of the variable named by the key, using the key provider
+// to find the encryption key. It fails if the encryption key is unknown, if the value was tampered with,
+// or if it was encrypted for another variable.
+func DecryptValue(key, value string, provider KeyProvider) (string, error) {
+	if !IsEncryptedValue(value) {
+		return "", fmt.Errorf("value is not wrapped as %s...%s", encryptedValuePrefix, encryptedValueSuffix)
+	}
+
+	parts := strings.SplitN(strings.TrimSuffix(strings.TrimPrefix(value, encryptedValuePrefix), encryptedValueSuffix), ":", 3)
+	if len(parts) != 3 || parts[0] != encryptedValueVersion {
+		return "", fmt.Errorf("unsupported encrypted value format")
+	}
+
+	secret, err := provider.Key(parts[1])
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("encrypted value is corrupted: %w", err)
+	}
+
+	plain, err := unseal(secret, sealed, additionalData(parts[0]+":"+parts[1], key))
+	if err != nil {
+		return "", err
+	}
+
+	return string(plain), nil
+}
+
+// additionalData binds the variable name to the encrypted value, so it can't be moved to another variable
+func additionalData(header, key string) []byte {
+	return []byte(header + ":" + key)
+}
@@ -0,0 +1,208 @@
+package envisage
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncryptValue(t *testing.T) {
+	keyring, _ := testKeyring(t, 1)
+	other, _ := testKeyring(t, 1)
+
+	encrypted, err := EncryptValue("T_ENC_TOKEN", "hunter2", keyring)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !IsEncryptedValue(encrypted) || !strings.HasPrefix(encrypted, "ENC[v1:"+keyring.PrimaryID()+":") {
+		t.Fatalf("failed. expecting ENC[...] value, got %s", encrypted)
+	}
+
+	if got, err := DecryptValue("T_ENC_TOKEN", encrypted, keyring); err != nil || got != "hunter2" {
+		t.Errorf("failed. expecting hunter2, got %q %v", got, err)
+	}
+
+	if _, err := DecryptValue("T_ENC_TOKEN", encrypted, other); !errors.Is(err, ErrNoKey) {
+		t.Errorf("failed. expecting ErrNoKey with the wrong key, got %v", err)
+	}
+
+	tampered := strings.Replace(encrypted, ":", ":0", 1)
+
+	if _, err := DecryptValue("T_ENC_TOKEN", tampered, keyring); err == nil {
+		t.Error("failed. expecting error with tampered key id")
+	}
+
+	i := strings.LastIndex(encrypted, ":") + 1
+	sealed, _ := base64.StdEncoding.DecodeString(encrypted[i : len(encrypted)-1])
+	sealed[len(sealed)-1] ^= 1
+	flipped := encrypted[:i] + base64.StdEncoding.EncodeToString(sealed) + "]"
+
+	if _, err := DecryptValue("T_ENC_TOKEN", flipped, keyring); err == nil {
+		t.Error("failed. expecting error with tampered ciphertext")
+	}
+
+	if _, err := DecryptValue("T_ENC_OTHER", encrypted, keyring); err == nil {
+		t.Error("failed. expecting error decrypting the value as another variable")
+	}
+}
+
+func TestGettersDecryptValues(t *testing.T) {
+	keyring, _ := testKeyring(t, 1)
+	other, _ := testKeyring(t, 1)
+
+	SetKeyProvider(keyring)
+	defer SetKeyProvider(nil)
+
+	password, err := EncryptValue("T_ENC_PASSWORD", "hunter2", keyring)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	port, err := EncryptValue("T_ENC_PORT", "5432", keyring)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wrongKey, err := EncryptValue("T_ENC_WRONG_KEY", "x", other)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("T_ENC_PASSWORD", password)
+	t.Setenv("T_ENC_PORT", port)
+	t.Setenv("T_ENC_WRONG_KEY", wrongKey)
+	t.Setenv("T_ENC_MOVED", password)
+
+	if got := Secret("T_ENC_PASSWORD", "").Reveal(); got != "hunter2" {
+		t.Errorf("failed. expecting hunter2, got %s", got)
+	}
+
+	if got := Int("T_ENC_PORT", 0); got != 5432 {
+		t.Errorf("failed. expecting 5432, got %d", got)
+	}
+
+	if !IsSensitive("T_ENC_PORT") {
+		t.Error("failed. expecting decrypted keys to be marked sensitive")
+	}
+
+	if _, _, err := Lookup("T_ENC_WRONG_KEY"); !errors.Is(err, ErrNoKey) || !strings.Contains(err.Error(), "T_ENC_WRONG_KEY") {
+		t.Errorf("failed. expecting ErrNoKey naming the key, got %v", err)
+	}
+
+	if _, _, err := Lookup("T_ENC_MOVED"); err == nil || !strings.Contains(err.Error(), "T_ENC_MOVED") {
+		t.Errorf("failed. expecting a value moved from another variable to fail, got %v", err)
+	}
+
+	var reported error
+
+	previous := SetErrorHandler(func(key string, err error) { reported = err })
+	defer SetErrorHandler(previous)
+
+	if got := String("T_ENC_WRONG_KEY", "default"); got != "default" || reported == nil {
+		t.Errorf("failed. expecting default value and a reported error, got %s %v", got, reported)
+	}
+}
+
+func TestLoadFromFileDecryptsValues(t *testing.T) {
+	keyring, _ := testKeyring(t, 1)
+
+	SetKeyProvider(keyring)
+	defer SetKeyProvider(nil)
+
+	password, err := EncryptValue("T_ENC_FILE_PASSWORD", "hunter2", keyring)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "values.env")
+
+	if err := os.WriteFile(path, []byte("T_ENC_FILE_USER=app\nT_ENC_FILE_PASSWORD="+password+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("T_ENC_FILE_USER", "")
+	t.Setenv("T_ENC_FILE_PASSWORD", "")
+
+	m, err := LoadFromFile(path, true, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m["T_ENC_FILE_USER"] != "app" || m["T_ENC_FILE_PASSWORD"] != "hunter2" {
+		t.Errorf("failed. expecting decrypted map, got %v", m)
+	}
+
+	if got := os.Getenv("T_ENC_FILE_PASSWORD"); got != password {
+		t.Errorf("failed. expecting environment to keep the encrypted value, got %s", got)
+	}
+
+	if got := String("T_ENC_FILE_PASSWORD", ""); got != "hunter2" {
+		t.Errorf("failed. expecting getter to decrypt, got %s", got)
+	}
+
+	SetKeyProvider(mustKeyring(t))
+
+	if _, err := LoadFromFile(path, false, false, true); err == nil {
+		t.Error("failed. expecting LoadFromFile to fail with the wrong key")
+	}
+}
+
+func mustKeyring(t *testing.T) *Keyring {
+	t.Helper()
+
+	k, _ := testKeyring(t, 1)
+
+	return k
+}
+
+func TestEncryptedValuesReadAsTheyAreWithoutKeyProvider(t *testing.T) {
+	keyring, keys := testKeyring(t, 1)
+
+	t.Setenv(KeyEnv, keys[0])
+
+	encrypted, err := EncryptValue("T_ENC_SEALED", "hunter2", keyring)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("T_ENC_LITERAL", "ENC[literal]")
+	t.Setenv("T_ENC_SEALED", encrypted)
+
+	var failures []string
+
+	previous := SetErrorHandler(func(key string, err error) {
+		failures = append(failures, key)
+	})
+	defer SetErrorHandler(previous)
+
+	if got := String("T_ENC_LITERAL", "default"); got != "ENC[literal]" {
+		t.Errorf("failed. expecting ENC[literal], got %s", got)
+	}
+
+	if got := String("T_ENC_SEALED", "default"); got != encrypted {
+		t.Errorf("failed. expecting the encrypted value without key provider, got %s", got)
+	}
+
+	if len(failures) > 0 {
+		t.Errorf("failed. expecting no errors, got %v", failures)
+	}
+
+	path := filepath.Join(t.TempDir(), ".env")
+
+	if err := os.WriteFile(path, []byte("T_ENC_TOKEN=ENC[abc]\nT_ENC_USER=app\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := LoadFromFile(path, false, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m["T_ENC_TOKEN"] != "ENC[abc]" || m["T_ENC_USER"] != "app" {
+		t.Errorf("failed. expecting values as they are, got %v", m)
+	}
+}
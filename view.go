@@ -0,0 +1,102 @@
+package envisage
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// View reads and writes the variables sharing a prefix, like BILLING_, so code receiving it uses short keys like HOST.
+// The prefix is prepended to keys verbatim: no separator is added, so include it, as in WithPrefix("BILLING_").
+// Every method behaves like the package function of the same name, errors naming the full key.
+// A View made by From reads from its own source instead of the one set by UseSource.
+type View struct {
+	prefix string
+	source Source
+}
+
+// WithPrefix returns a View of the variables whose names start with prefix.
+// No separator is added between the prefix and the keys, so WithPrefix("BILLING_").String("HOST", "") reads BILLING_HOST.
+func WithPrefix(prefix string) View {
+	return View{prefix: prefix}
+}
+
+// From returns a View reading from source, whatever UseSource was given. Setters write to source if it's a WritableSource,
+// or else return an error, so code receiving the View can be tested against a fake source without touching the process environment.
+func From(source Source) View {
+	return View{source: source}
+}
+
+// WithPrefix returns a nested View, its prefix appended to the current one, so
+// WithPrefix("BILLING_").WithPrefix("DB_") reads BILLING_DB_HOST for HOST
+func (v View) WithPrefix(prefix string) View {
+	return View{prefix: v.prefix + prefix, source: v.source}
+}
+
+// KeyPrefix returns the prefix prepended to every key
+func (v View) KeyPrefix() string {
+	return v.prefix
+}
+
+// Key returns the full name of the variable, with the prefix, for functions not available as View methods,
+// like MapOf or the Rule keys given to Validate
+func (v View) Key(key string) string {
+	return v.prefix + key
+}
+
+// Keys returns, sorted, the names of the variables present under the prefix, with the prefix stripped
+func (v View) Keys() []string {
+	var keys []string
+
+	for _, k := range v.activeSource().Keys() {
+		if rest, found := strings.CutPrefix(k, v.prefix); found && rest != "" {
+			keys = append(keys, rest)
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// at returns the full key, and the view without prefix, so method bodies deal with full keys only
+func (v View) at(key string) (View, string) {
+	return View{source: v.source}, v.prefix + key
+}
+
+// activeSource returns the source given to From, or the one set by UseSource
+func (v View) activeSource() Source {
+	if v.source == nil {
+		return activeSource()
+	}
+
+	return v.source
+}
+
+// errReadOnly is returned by setters of a View made by From with a source that isn't a WritableSource
+var errReadOnly = errors.New("the view source is read-only")
+
+// writable returns the source setters write to: the view source if it's a WritableSource, or an error if the view was
+// made by From with a read-only one. Only the default View falls back to the process environment, as documented by UseSource.
+func (v View) writable() (WritableSource, error) {
+	if w, ok := v.activeSource().(WritableSource); ok {
+		return w, nil
+	}
+
+	if v.source != nil {
+		return nil, errReadOnly
+	}
+
+	return environment{}, nil
+}
+
+// set writes the full key where the setters write, as returned by writable
+func (v View) set(key, value string) error {
+	target, err := v.writable()
+	if err != nil {
+		return fmt.Errorf("environment variable %s can't be set: %w", key, err)
+	}
+
+	return target.Set(key, value)
+}
@@ -0,0 +1,195 @@
+package envisage
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWithPrefix(t *testing.T) {
+	t.Setenv("T_VIEW_BILLING_HOST", "billing.local")
+	t.Setenv("T_VIEW_BILLING_PORT", "8080")
+	t.Setenv("T_VIEW_BILLING_DB_HOST", "db.local")
+	t.Setenv("T_VIEW_SEARCH_HOST", "search.local")
+
+	billing := WithPrefix("T_VIEW_BILLING_")
+
+	if got := billing.String("HOST", ""); got != "billing.local" {
+		t.Errorf("failed. expecting billing.local, got %s", got)
+	}
+
+	if got := billing.Int("PORT", 0); got != 8080 {
+		t.Errorf("failed. expecting 8080, got %d", got)
+	}
+
+	db := billing.WithPrefix("DB_")
+
+	if got := db.KeyPrefix(); got != "T_VIEW_BILLING_DB_" {
+		t.Errorf("failed. expecting nested prefix, got %s", got)
+	}
+
+	if got := db.String("HOST", ""); got != "db.local" {
+		t.Errorf("failed. expecting db.local, got %s", got)
+	}
+
+	if err := db.SetInt("PORT", 5432); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		_ = os.Unsetenv("T_VIEW_BILLING_DB_PORT")
+	})
+
+	if got := Int("T_VIEW_BILLING_DB_PORT", 0); got != 5432 {
+		t.Errorf("failed. expecting setter to write the prefixed key, got %d", got)
+	}
+
+	if expected, got := []string{"DB_HOST", "DB_PORT", "HOST", "PORT"}, billing.Keys(); !reflect.DeepEqual(expected, got) {
+		t.Errorf("failed. expecting %v, got %v", expected, got)
+	}
+
+	if expected, got := []string{"HOST", "PORT"}, db.Keys(); !reflect.DeepEqual(expected, got) {
+		t.Errorf("failed. expecting %v, got %v", expected, got)
+	}
+
+	t.Setenv("T_VIEW_BILLING_MODE", "x")
+
+	_, err := billing.ParseOneOf("MODE", []string{"live", "test"}, false, "test")
+	if err == nil || !strings.Contains(err.Error(), "T_VIEW_BILLING_MODE") {
+		t.Errorf("failed. expecting error naming the full key, got %v", err)
+	}
+}
+
+func TestWithPrefixReadsActiveSource(t *testing.T) {
+	dir := createConfigMapDir(t, map[string]string{"T_VIEW_DIR_LEVEL": "debug"})
+
+	source, err := Dir(dir, DirOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	UseSource(source)
+	defer UseSource(nil)
+
+	view := WithPrefix("T_VIEW_DIR_")
+
+	if got := view.String("LEVEL", ""); got != "debug" {
+		t.Errorf("failed. expecting debug, got %s", got)
+	}
+
+	if expected, got := []string{"LEVEL"}, view.Keys(); !reflect.DeepEqual(expected, got) {
+		t.Errorf("failed. expecting %v, got %v", expected, got)
+	}
+}
+
+func TestFrom(t *testing.T) {
+	dir := createConfigMapDir(t, map[string]string{"T_FROM_LEVEL": "debug", "T_FROM_PORT": "8080"})
+
+	source, err := Dir(dir, DirOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("T_FROM_LEVEL", "info")
+
+	view := From(source).WithPrefix("T_FROM_")
+
+	if got := view.String("LEVEL", ""); got != "debug" {
+		t.Errorf("failed. expecting the view source over the process environment, got %s", got)
+	}
+
+	if got := view.Int("PORT", 0); got != 8080 {
+		t.Errorf("failed. expecting 8080, got %d", got)
+	}
+
+	if got := String("T_FROM_LEVEL", ""); got != "info" {
+		t.Errorf("failed. expecting package getters to keep reading the process environment, got %s", got)
+	}
+
+	if err := view.SetString("MODE", "live"); err == nil || !strings.Contains(err.Error(), "T_FROM_MODE") {
+		t.Errorf("failed. expecting a read-only source to fail setters naming the key, got %v", err)
+	}
+
+	if err := view.SetPairs(Pair{Key: "MODE", Value: "live"}); err == nil {
+		t.Error("failed. expecting a read-only source to fail SetPairs")
+	}
+
+	if _, ok := os.LookupEnv("T_FROM_MODE"); ok {
+		t.Error("failed. expecting the process environment untouched")
+	}
+}
+
+// testWritableSource is a map-backed WritableSource, failing to set values holding a NUL byte like the process environment does
+type testWritableSource map[string]string
+
+func (s testWritableSource) Lookup(key string) (string, bool, error) {
+	v, ok := s[key]
+
+	return v, ok, nil
+}
+
+func (s testWritableSource) Keys() []string {
+	keys := make([]string, 0, len(s))
+
+	for k := range s {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
+
+func (s testWritableSource) Set(key, value string) error {
+	if strings.Contains(value, "\x00") {
+		return fmt.Errorf("invalid value")
+	}
+
+	s[key] = value
+
+	return nil
+}
+
+func (s testWritableSource) Unset(key string) error {
+	delete(s, key)
+
+	return nil
+}
+
+func TestWritableSource(t *testing.T) {
+	source := testWritableSource{"T_WRITABLE_HOST": "a.local"}
+
+	UseSource(source)
+	defer UseSource(nil)
+
+	if err := SetInt("T_WRITABLE_PORT", 8080); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := os.LookupEnv("T_WRITABLE_PORT"); ok {
+		t.Error("failed. expecting setter to write to the source, not to the process environment")
+	}
+
+	if got := Int("T_WRITABLE_PORT", 0); got != 8080 {
+		t.Errorf("failed. expecting 8080, got %d", got)
+	}
+
+	err := SetPairs(Pair{Key: "T_WRITABLE_HOST", Value: "b.local"}, Pair{Key: "T_WRITABLE_NEW", Value: "x"}, Pair{Key: "T_WRITABLE_BAD", Value: "\x00"})
+	if err == nil {
+		t.Fatal("failed. expecting error setting a NUL byte")
+	}
+
+	expected := testWritableSource{"T_WRITABLE_HOST": "a.local", "T_WRITABLE_PORT": "8080"}
+
+	if !reflect.DeepEqual(expected, source) {
+		t.Errorf("failed. expecting rollback in the source, got %v", source)
+	}
+
+	if err := From(source).WithPrefix("T_WRITABLE_").SetString("MODE", "live"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := source["T_WRITABLE_MODE"]; got != "live" {
+		t.Errorf("failed. expecting view setter to write to its source, got %s", got)
+	}
+}